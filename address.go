@@ -0,0 +1,163 @@
+package polynym
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+)
+
+// AddressNetwork is the network of a BitcoinSV address
+type AddressNetwork string
+
+// AddressType is the type of script a BitcoinSV address pays to
+type AddressType string
+
+// Supported networks and address types
+const (
+	NetworkMainnet AddressNetwork = "mainnet" // NetworkMainnet is the main BitcoinSV network
+	NetworkTestnet AddressNetwork = "testnet" // NetworkTestnet is the BitcoinSV test network
+	AddressP2PKH   AddressType    = "p2pkh"   // AddressP2PKH is a pay-to-public-key-hash address
+	AddressP2SH    AddressType    = "p2sh"    // AddressP2SH is a pay-to-script-hash address
+)
+
+// Address version bytes
+const (
+	versionMainnetP2PKH byte = 0x00
+	versionMainnetP2SH  byte = 0x05
+	versionTestnetP2PKH byte = 0x6f
+	versionTestnetP2SH  byte = 0xc4
+)
+
+// AddressInfo is the decoded BitcoinSV address
+type AddressInfo struct {
+	Hash    []byte         `json:"hash"`    // Hash is the 20 byte public key hash or script hash
+	Network AddressNetwork `json:"network"` // Network is mainnet or testnet
+	Type    AddressType    `json:"type"`    // Type is p2pkh or p2sh
+	Version byte           `json:"version"` // Version is the version byte of the address
+}
+
+// DecodeAddress will decode and validate a BitcoinSV address (Base58Check) without any network requests
+//
+// The version byte must be a mainnet or testnet P2PKH or P2SH address and the checksum must match.
+// Errors match ErrInvalidInput and the specific reason (ErrInvalidBase58, ErrInvalidChecksum, etc)
+func DecodeAddress(address string) (*AddressInfo, error) {
+
+	// Decode the Base58 string
+	decoded, err := base58Decode(address)
+	if err != nil {
+		return nil, newResolveError(ErrInvalidInput, nil, "invalid address: "+address, err)
+	}
+
+	// Version (1) + hash (20) + checksum (4)
+	if len(decoded) != 25 {
+		return nil, newResolveError(ErrInvalidInput, nil, "invalid address: "+address, ErrInvalidAddressLength)
+	}
+
+	// Verify the checksum (first 4 bytes of double sha256)
+	if !bytes.Equal(checksum(decoded[:21]), decoded[21:]) {
+		return nil, newResolveError(ErrInvalidInput, nil, "invalid address: "+address, ErrInvalidChecksum)
+	}
+
+	// Detect the network and type from the version
+	info := &AddressInfo{Hash: decoded[1:21], Version: decoded[0]}
+	switch decoded[0] {
+	case versionMainnetP2PKH:
+		info.Network, info.Type = NetworkMainnet, AddressP2PKH
+	case versionMainnetP2SH:
+		info.Network, info.Type = NetworkMainnet, AddressP2SH
+	case versionTestnetP2PKH:
+		info.Network, info.Type = NetworkTestnet, AddressP2PKH
+	case versionTestnetP2SH:
+		info.Network, info.Type = NetworkTestnet, AddressP2SH
+	default:
+		return nil, newResolveError(ErrInvalidInput, nil, "invalid address: "+address, ErrInvalidAddressVersion)
+	}
+
+	return info, nil
+}
+
+// IsValidAddress returns true if the address is a valid BitcoinSV address (Base58Check)
+func IsValidAddress(address string) bool {
+	_, err := DecodeAddress(address)
+	return err == nil
+}
+
+// AddressFromScript will decode a locking script (hex) into a mainnet address
+//
+// Supports P2PKH (76a914{hash}88ac) and P2SH (a914{hash}87) scripts
+func AddressFromScript(script string) (string, error) {
+	decoded, err := hex.DecodeString(script)
+	if err != nil {
+		return "", newResolveError(ErrDecodeFailure, nil, "invalid output script: "+script, err)
+	}
+
+	switch {
+	case len(decoded) == 25 && decoded[0] == 0x76 && decoded[1] == 0xa9 && decoded[2] == 0x14 &&
+		decoded[23] == 0x88 && decoded[24] == 0xac:
+		return encodeAddress(versionMainnetP2PKH, decoded[3:23]), nil
+	case len(decoded) == 23 && decoded[0] == 0xa9 && decoded[1] == 0x14 && decoded[22] == 0x87:
+		return encodeAddress(versionMainnetP2SH, decoded[2:22]), nil
+	}
+	return "", newResolveError(ErrDecodeFailure, nil, "unsupported output script: "+script, nil)
+}
+
+// encodeAddress will encode the version and hash into an address (Base58Check)
+func encodeAddress(version byte, hash []byte) string {
+	payload := append([]byte{version}, hash...)
+	return base58Encode(append(payload, checksum(payload)...))
+}
+
+// checksum returns the first 4 bytes of the double sha256 of the payload
+func checksum(payload []byte) []byte {
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
+
+// base58Decode will decode a Base58 string into bytes (leading 1s are zero bytes)
+func base58Decode(input string) ([]byte, error) {
+	if len(input) == 0 {
+		return nil, ErrInvalidBase58
+	}
+
+	result := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(input); i++ {
+		index := bytes.IndexByte([]byte(base58Alphabet), input[i])
+		if index < 0 {
+			return nil, ErrInvalidBase58
+		}
+		result.Mul(result, radix)
+		result.Add(result, big.NewInt(int64(index)))
+	}
+
+	// Restore the leading zero bytes
+	var zeros int
+	for zeros < len(input) && input[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), result.Bytes()...), nil
+}
+
+// base58Encode will encode bytes into a Base58 string (leading zero bytes are 1s)
+func base58Encode(input []byte) string {
+	value := new(big.Int).SetBytes(input)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var encoded []byte
+	for value.Sign() > 0 {
+		value.DivMod(value, radix, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	for i := 0; i < len(input) && input[i] == 0; i++ {
+		encoded = append(encoded, base58Alphabet[0])
+	}
+
+	// Reverse (most significant first)
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
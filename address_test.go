@@ -0,0 +1,130 @@
+package polynym
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestDecodeAddress will test the DecodeAddress() method
+func TestDecodeAddress(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		input           string
+		expectedNetwork AddressNetwork
+		expectedType    AddressType
+		expectedError   error
+	}{
+		{"16L5yRNPTuciSgXGHqYwn9N6NeoKqopAu", NetworkMainnet, AddressP2PKH, nil},
+		{"31nM1WuowNDzocNxPPW9NQWJEtwWpjfcLj", NetworkMainnet, AddressP2SH, nil},
+		{"mfcHP2WMCVLsVZA8yrovmhMgxNFW9r98xw", NetworkTestnet, AddressP2PKH, nil},
+		{"2MsLZ5FqqYpjM1Q1W4X81zMVZTF9gdbhVwd", NetworkTestnet, AddressP2SH, nil},
+		{"19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", NetworkMainnet, AddressP2PKH, nil},
+		{"16L5yRNPTuciSgXGHqYwn9N6NeoKqopAv", "", "", ErrInvalidChecksum},
+		{"16L5yRNPTuciSgXGHqYwn9N6NeoKqopA0", "", "", ErrInvalidBase58},
+		{"", "", "", ErrInvalidBase58},
+		{"16L5yRNPTuciSgX", "", "", ErrInvalidAddressLength},
+		{"LKKHMBjCU89fyFNgSRprDoD8Jb25N8uWvd", "", "", ErrInvalidAddressVersion},
+	}
+
+	// Test all
+	for _, test := range tests {
+		info, err := DecodeAddress(test.input)
+		if test.expectedError != nil {
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.input, test.expectedError, err)
+			} else if !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.input, ErrInvalidInput, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if info.Network != test.expectedNetwork || info.Type != test.expectedType {
+			t.Errorf("%s Failed: [%s] inputted and [%s/%s] expected, received: [%s/%s]", t.Name(), test.input, test.expectedNetwork, test.expectedType, info.Network, info.Type)
+		} else if len(info.Hash) != 20 {
+			t.Errorf("%s Failed: [%s] inputted and expected a 20 byte hash, received: [%d]", t.Name(), test.input, len(info.Hash))
+		}
+	}
+}
+
+// TestDecodeAddress_Hash will test the decoded hash of an address
+func TestDecodeAddress_Hash(t *testing.T) {
+	t.Parallel()
+
+	info, err := DecodeAddress("16L5yRNPTuciSgXGHqYwn9N6NeoKqopAu")
+	if err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	} else if hex.EncodeToString(info.Hash) != "0102030405060708090a0b0c0d0e0f1011121314" {
+		t.Fatalf("%s Failed: unexpected hash [%x]", t.Name(), info.Hash)
+	}
+}
+
+// TestGetAddress_LocalAddress will make sure addresses are resolved without a request
+func TestGetAddress_LocalAddress(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockHTTPUnavailable{}
+	client := &Client{httpClient: mock, UserAgent: defaultUserAgent}
+
+	if output, err := client.GetAddress("16L5yRNPTuciSgXGHqYwn9N6NeoKqopAu"); err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	} else if output.Address != "16L5yRNPTuciSgXGHqYwn9N6NeoKqopAu" {
+		t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "16L5yRNPTuciSgXGHqYwn9N6NeoKqopAu", output.Address)
+	}
+
+	if _, err := client.GetAddress("16L5yRNPTuciSgXGHqYwn9N6NeoKqopAv"); !errors.Is(err, ErrInvalidChecksum) {
+		t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrInvalidChecksum, err)
+	}
+
+	if mock.attempts != 0 {
+		t.Fatalf("%s Failed: expected no requests, received: [%d]", t.Name(), mock.attempts)
+	}
+}
+
+// TestAddressFromScript will test the AddressFromScript() method
+func TestAddressFromScript(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		input         string
+		expected      string
+		expectedError bool
+	}{
+		{"76a9140102030405060708090a0b0c0d0e0f101112131488ac", "16L5yRNPTuciSgXGHqYwn9N6NeoKqopAu", false},
+		{"a9140102030405060708090a0b0c0d0e0f101112131487", "31nM1WuowNDzocNxPPW9NQWJEtwWpjfcLj", false},
+		{"76a9140000000000000000000000000000000000000000088ac", "", true},
+		{"76a914000000000000000000000000000000000000000088ac", "1111111111111111111114oLvT2", false},
+		{"006a0568656c6c6f", "", true},
+		{"not-hex", "", true},
+		{"", "", true},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if output, err := AddressFromScript(test.input); err == nil && test.expectedError {
+			t.Errorf("%s Failed: expected to throw an error, no error [%s] inputted", t.Name(), test.input)
+		} else if err != nil && !test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if err != nil && !errors.Is(err, ErrDecodeFailure) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.input, ErrDecodeFailure, err)
+		} else if output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expected, output)
+		}
+	}
+}
+
+// ExampleIsValidAddress example using IsValidAddress()
+func ExampleIsValidAddress() {
+	fmt.Println(IsValidAddress("19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h"))
+	// Output:true
+}
+
+// BenchmarkDecodeAddress benchmarks the DecodeAddress method
+func BenchmarkDecodeAddress(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = DecodeAddress("19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h")
+	}
+}
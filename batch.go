@@ -0,0 +1,101 @@
+package polynym
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultBatchWorkers is the default number of concurrent lookups for GetAddresses()
+const defaultBatchWorkers = 5
+
+// BatchOptions is the configuration for resolving many identifiers with GetAddresses()
+type BatchOptions struct {
+	Timeout time.Duration `json:"timeout"` // Timeout is the overall deadline for the batch (0 is no deadline)
+	Workers int           `json:"workers"` // Workers is the number of concurrent lookups (default: 5)
+}
+
+// BatchResult is the result of resolving one input with GetAddresses()
+type BatchResult struct {
+	Error    error               `json:"-"`        // Error is the error resolving the input (if any)
+	Input    string              `json:"input"`    // Input is the original input
+	Response *GetAddressResponse `json:"response"` // Response is the response for the input
+}
+
+// GetAddresses will resolve many handles, paymails or addresses concurrently
+//
+// Results are returned in the same order as the inputs, each with their own error. Equivalent inputs
+// ($MrZ and mrz@handcash.io) are only resolved once. The number of concurrent lookups is limited by
+// the workers, and the timeout is applied to the whole batch on top of the context.
+func (c *Client) GetAddresses(ctx context.Context, inputs []string, opts *BatchOptions) []*BatchResult {
+
+	// Set the defaults
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+
+	// Apply the overall deadline
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	// Group the inputs by their canonical identifier (invalid inputs are kept separate)
+	results := make([]*BatchResult, len(inputs))
+	groups := make(map[string][]int)
+	var keys []string
+	for i, input := range inputs {
+		results[i] = &BatchResult{Input: input}
+		key := c.canonicalKey(input)
+		if len(key) == 0 {
+			key = "\x00" + input
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	// Start the workers
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	if workers > len(keys) {
+		workers = len(keys)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				indexes := groups[key]
+				response, err := c.GetAddressWithContext(ctx, inputs[indexes[0]])
+				for _, i := range indexes {
+					results[i].Response, results[i].Error = copyResponse(response), copyError(err)
+				}
+			}
+		}()
+	}
+
+	// Send the jobs (in input order)
+	for _, key := range keys {
+		jobs <- key
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// canonicalKey returns the normalized identifier for the input (empty if invalid)
+func (c *Client) canonicalKey(input string) string {
+	_, normalized, err := c.classify(input)
+	if err != nil {
+		return ""
+	}
+	return normalized
+}
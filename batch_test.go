@@ -0,0 +1,119 @@
+package polynym
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestGetAddresses will test the GetAddresses() method
+func TestGetAddresses(t *testing.T) {
+	t.Parallel()
+
+	t.Run("results are in input order", func(t *testing.T) {
+		client := newMockClient(defaultUserAgent)
+		inputs := []string{"1mrz", "bad@paymailaddress.com", "$mr-z", "", "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h"}
+		expected := []string{"1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa", "", "124dwBFyFtkcNXGfVWQroGcT9ybnpQ3G3Z", "", "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h"}
+		expectedErrors := []error{nil, ErrNotFound, nil, ErrInvalidInput, nil}
+
+		results := client.GetAddresses(context.Background(), inputs, &BatchOptions{Workers: 2})
+		if len(results) != len(inputs) {
+			t.Fatalf("%s Failed: expected [%d] results, received: [%d]", t.Name(), len(inputs), len(results))
+		}
+		for i, result := range results {
+			if result.Input != inputs[i] {
+				t.Errorf("%s Failed: [%d] expected input [%s], received: [%s]", t.Name(), i, inputs[i], result.Input)
+			} else if expectedErrors[i] != nil && !errors.Is(result.Error, expectedErrors[i]) {
+				t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), inputs[i], expectedErrors[i], result.Error)
+			} else if expectedErrors[i] == nil && result.Error != nil {
+				t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), inputs[i], result.Error.Error())
+			} else if result.Error == nil && result.Response.Address != expected[i] {
+				t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), inputs[i], expected[i], result.Response.Address)
+			}
+		}
+	})
+
+	t.Run("equivalent inputs are resolved once", func(t *testing.T) {
+		mock := &mockHTTPCounter{}
+		client := &Client{httpClient: mock, UserAgent: defaultUserAgent}
+		results := client.GetAddresses(context.Background(), []string{"$MrZ", "mrz@handcash.io", "MRZ@HANDCASH.IO", "1mrz"}, nil)
+		for _, result := range results {
+			if result.Error != nil {
+				t.Fatalf("%s Failed: [%s] inputted, received error [%s]", t.Name(), result.Input, result.Error.Error())
+			}
+		}
+		if results[0].Response.Address != results[2].Response.Address {
+			t.Fatalf("%s Failed: expected the same address for equivalent inputs", t.Name())
+		} else if mock.count() != 2 {
+			t.Fatalf("%s Failed: expected [%d] requests, received: [%d]", t.Name(), 2, mock.count())
+		}
+
+		// Every index has its own copy of the response
+		results[0].Response.Address = "changed"
+		results[0].Response.LastRequest.URL = "changed"
+		if results[1].Response.Address == "changed" || results[1].Response.LastRequest.URL == "changed" {
+			t.Fatalf("%s Failed: the responses of equivalent inputs should not be shared", t.Name())
+		}
+	})
+
+	t.Run("equivalent inputs do not share errors", func(t *testing.T) {
+		client := newMockClient(defaultUserAgent)
+		results := client.GetAddresses(context.Background(), []string{"bad@paymailaddress.com", "BAD@paymailaddress.com"}, nil)
+		var first, second *ResolveError
+		if !errors.As(results[0].Error, &first) || !errors.As(results[1].Error, &second) {
+			t.Fatalf("%s Failed: expected resolve errors, received: [%v] [%v]", t.Name(), results[0].Error, results[1].Error)
+		} else if first == second || (first.LastRequest != nil && first.LastRequest == second.LastRequest) {
+			t.Fatalf("%s Failed: the errors of equivalent inputs should not be shared", t.Name())
+		} else if !errors.Is(second, ErrNotFound) {
+			t.Fatalf("%s Failed: expected [%v], received: [%v]", t.Name(), ErrNotFound, second)
+		}
+	})
+
+	t.Run("workers limit the concurrency", func(t *testing.T) {
+		mock := &mockHTTPConcurrent{}
+		client := &Client{httpClient: mock, UserAgent: defaultUserAgent}
+		var inputs []string
+		for i := 0; i < 20; i++ {
+			inputs = append(inputs, fmt.Sprintf("user%d@handcash.io", i))
+		}
+		_ = client.GetAddresses(context.Background(), inputs, &BatchOptions{Workers: 3})
+		if maxConcurrent := atomic.LoadInt64(&mock.max); maxConcurrent > 3 || maxConcurrent < 2 {
+			t.Fatalf("%s Failed: expected at most [%d] concurrent requests, received: [%d]", t.Name(), 3, maxConcurrent)
+		}
+	})
+
+	t.Run("timeout applies to the whole batch", func(t *testing.T) {
+		client := &Client{httpClient: &mockHTTPBlocking{}, UserAgent: defaultUserAgent}
+		start := time.Now()
+		results := client.GetAddresses(context.Background(), []string{"1mrz", "$mrz", "mrz@moneybutton.com"}, &BatchOptions{Timeout: 10 * time.Millisecond, Workers: 1})
+		if time.Since(start) > time.Second {
+			t.Fatalf("%s Failed: the batch took too long", t.Name())
+		}
+		for _, result := range results {
+			if !errors.Is(result.Error, context.DeadlineExceeded) {
+				t.Fatalf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), result.Input, context.DeadlineExceeded, result.Error)
+			}
+		}
+	})
+
+	t.Run("no inputs", func(t *testing.T) {
+		client := newMockClient(defaultUserAgent)
+		if results := client.GetAddresses(context.Background(), nil, nil); len(results) != 0 {
+			t.Fatalf("%s Failed: expected no results, received: [%d]", t.Name(), len(results))
+		}
+	})
+}
+
+// ExampleClient_GetAddresses example using GetAddresses()
+func ExampleClient_GetAddresses() {
+	client := newMockClient(defaultUserAgent)
+	results := client.GetAddresses(context.Background(), []string{"1mrz", "$mr-z"}, &BatchOptions{Workers: 2})
+	for _, result := range results {
+		fmt.Println(result.Input, result.Response.Address)
+	}
+	// Output:1mrz 1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa
+	// $mr-z 124dwBFyFtkcNXGfVWQroGcT9ybnpQ3G3Z
+}
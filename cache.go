@@ -0,0 +1,173 @@
+package polynym
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// cacheKeyPrefix is the prefix for all the keys stored in the cache (useful for shared stores)
+const cacheKeyPrefix = "polynym:"
+
+// cacheEntry is a resolved address (or not found result) stored in the cache
+type cacheEntry struct {
+	Address      string `json:"address"`       // Address is the resolved address (empty if not found)
+	ErrorMessage string `json:"error_message"` // ErrorMessage is the upstream message for a not found result
+	StatusCode   int    `json:"status_code"`   // StatusCode is the status of the original request
+	URL          string `json:"url"`           // URL is the url of the original request
+}
+
+// toResponse will convert the cache entry into a response (and error for a not found result)
+func (e *cacheEntry) toResponse() (*GetAddressResponse, error) {
+	response := &GetAddressResponse{
+		Address:      e.Address,
+		CacheHit:     true,
+		ErrorMessage: e.ErrorMessage,
+		LastRequest: &LastRequest{
+			Method:     http.MethodGet,
+			StatusCode: e.StatusCode,
+			URL:        e.URL,
+		},
+	}
+	if len(e.Address) == 0 {
+		return response, newResolveError(ErrNotFound, response.LastRequest, e.ErrorMessage, nil)
+	}
+	return response, nil
+}
+
+// MemoryCache is an in-memory Cache with TTL expiration and LRU eviction
+type MemoryCache struct {
+	entries    map[string]*list.Element // entries by key
+	lru        *list.List               // most recently used at the front
+	maxEntries int                      // max number of entries (0 is unlimited)
+	mu         sync.Mutex               // guards the entries and the list
+}
+
+// memoryCacheItem is an element in the LRU list
+type memoryCacheItem struct {
+	expiresAt time.Time
+	key       string
+	value     []byte
+}
+
+// NewMemoryCache will create a new in-memory cache (maxEntries of 0 is unlimited)
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+	}
+}
+
+// Get will return the value if found and not expired
+func (m *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	item := element.Value.(*memoryCacheItem)
+	if time.Now().After(item.expiresAt) {
+		m.lru.Remove(element)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	m.lru.MoveToFront(element)
+	return item.value, true, nil
+}
+
+// Set will store the value, evicting the least recently used entry if full
+func (m *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		item := element.Value.(*memoryCacheItem)
+		item.expiresAt, item.value = time.Now().Add(ttl), value
+		m.lru.MoveToFront(element)
+		return nil
+	}
+	m.entries[key] = m.lru.PushFront(&memoryCacheItem{expiresAt: time.Now().Add(ttl), key: key, value: value})
+	if m.maxEntries > 0 && m.lru.Len() > m.maxEntries {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+	return nil
+}
+
+// Delete will remove the value (if found)
+func (m *MemoryCache) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		m.lru.Remove(element)
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// Len returns the number of entries (including expired entries not yet removed)
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+// cacheGet will return the cached entry for the canonical identifier (if the cache is enabled)
+//
+// Errors from the cache are logged and treated as a miss
+func (c *Client) cacheGet(ctx context.Context, key string) *cacheEntry {
+	if c.cache == nil {
+		return nil
+	}
+	value, found, err := c.cache.Get(ctx, cacheKeyPrefix+key)
+	if err != nil {
+		c.log(ctx, LogLevelWarn, "cache error", map[string]interface{}{"error_type": cacheErrorType(err), "operation": "get"})
+		return nil
+	} else if !found {
+		return nil
+	}
+	entry := new(cacheEntry)
+	if err = json.Unmarshal(value, entry); err != nil {
+		return nil
+	}
+	return entry
+}
+
+// cacheSet will cache a resolved address (positive TTL) or a not found result (negative TTL)
+//
+// Errors from the cache are only logged, the address was still resolved
+func (c *Client) cacheSet(ctx context.Context, key string, response *GetAddressResponse, err error) {
+	if c.cache == nil || response == nil || response.LastRequest == nil {
+		return
+	}
+
+	// Determine the TTL based on the result (other errors are not cached)
+	ttl := c.cacheTTL
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			return
+		}
+		ttl = c.cacheNegativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	value, _ := json.Marshal(&cacheEntry{ // nolint: errchkjson // only strings and ints
+		Address:      response.Address,
+		ErrorMessage: response.ErrorMessage,
+		StatusCode:   response.LastRequest.StatusCode,
+		URL:          response.LastRequest.URL,
+	})
+	if setErr := c.cache.Set(ctx, cacheKeyPrefix+key, value, ttl); setErr != nil {
+		c.log(ctx, LogLevelWarn, "cache error", map[string]interface{}{"error_type": cacheErrorType(setErr), "operation": "set"})
+		return
+	}
+	c.log(ctx, LogLevelDebug, "cache stored", map[string]interface{}{"status": response.LastRequest.StatusCode, "ttl_ms": durationMS(ttl)})
+}
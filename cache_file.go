@@ -0,0 +1,153 @@
+package polynym
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// fileCacheSweepInterval is the number of Set calls between sweeps of the directory
+	fileCacheSweepInterval = 256
+
+	// fileCacheTempMaxAge is the age of a temp file left behind by a failed write before it is removed
+	fileCacheTempMaxAge = time.Hour
+)
+
+// FileCache is a Cache that persists each entry to a file in a directory
+//
+// Entries are written atomically (temp file and rename), so a restarted process comes back warm.
+// Expired, corrupt and stale temp files are swept when the cache is created and every few hundred writes
+type FileCache struct {
+	directory string
+	sets      uint32 // number of Set calls (used to schedule the sweeps)
+}
+
+// fileCacheItem is the contents of a cache file
+type fileCacheItem struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+	Value     []byte    `json:"value"`
+}
+
+// NewFileCache will create a new file-backed cache in the directory (created if missing)
+func NewFileCache(directory string) (*FileCache, error) {
+	if err := os.MkdirAll(directory, 0o700); err != nil {
+		return nil, err
+	}
+	f := &FileCache{directory: directory}
+	f.sweep()
+	return f, nil
+}
+
+// Get will return the value if found and not expired (expired and corrupt files are removed)
+func (f *FileCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	contents, err := os.ReadFile(f.path(key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	item := new(fileCacheItem)
+	if err = json.Unmarshal(contents, item); err != nil {
+		_ = f.remove(key)
+		return nil, false, err
+	}
+
+	// Expired (or a hash collision)
+	if item.Key != key {
+		return nil, false, nil
+	} else if time.Now().After(item.ExpiresAt) {
+		return nil, false, f.remove(key)
+	}
+	return item.Value, true, nil
+}
+
+// Set will store the value in a file (replacing any existing file atomically)
+func (f *FileCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	if atomic.AddUint32(&f.sets, 1)%fileCacheSweepInterval == 0 {
+		f.sweep()
+	}
+
+	contents, err := json.Marshal(&fileCacheItem{ExpiresAt: time.Now().Add(ttl), Key: key, Value: value})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file in the same directory, then rename over the existing file
+	var tmp *os.File
+	if tmp, err = os.CreateTemp(f.directory, ".tmp-*"); err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err = tmp.Write(contents); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(key))
+}
+
+// Delete will remove the value (if found)
+func (f *FileCache) Delete(_ context.Context, key string) error {
+	return f.remove(key)
+}
+
+// remove will remove the file for the key (missing files are ignored)
+func (f *FileCache) remove(key string) error {
+	if err := os.Remove(f.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path returns the file path for the key (hashed to be safe for any file system)
+func (f *FileCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(f.directory, hex.EncodeToString(hash[:])+".json")
+}
+
+// sweep will remove the expired and corrupt entries and any stale temp files (best effort, errors are ignored)
+func (f *FileCache) sweep() {
+	files, err := os.ReadDir(f.directory)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, file := range files {
+		name := filepath.Join(f.directory, file.Name())
+		if file.IsDir() {
+			continue
+		} else if strings.HasPrefix(file.Name(), ".tmp-") {
+			if info, infoErr := file.Info(); infoErr == nil && now.Sub(info.ModTime()) > fileCacheTempMaxAge {
+				_ = os.Remove(name)
+			}
+			continue
+		} else if filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		contents, readErr := os.ReadFile(name) // nolint: gosec // the name comes from the cache directory
+		if readErr != nil {
+			continue
+		}
+		item := new(fileCacheItem)
+		if err = json.Unmarshal(contents, item); err != nil || now.After(item.ExpiresAt) {
+			_ = os.Remove(name)
+		}
+	}
+}
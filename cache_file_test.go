@@ -0,0 +1,138 @@
+package polynym
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFileCache will test the FileCache get/set/delete and expiration
+func TestFileCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("get, set and delete", func(t *testing.T) {
+		cache, err := NewFileCache(filepath.Join(t.TempDir(), "cache"))
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		if _, found, err := cache.Get(ctx, "mrz@handcash.io"); err != nil || found {
+			t.Fatalf("%s Failed: expected no entry, error [%v]", t.Name(), err)
+		}
+		if err = cache.Set(ctx, "mrz@handcash.io", []byte("19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h"), time.Minute); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		if value, found, err := cache.Get(ctx, "mrz@handcash.io"); err != nil || !found || string(value) != "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h" {
+			t.Fatalf("%s Failed: expected the entry, received: [%s] error [%v]", t.Name(), value, err)
+		}
+		if err = cache.Delete(ctx, "mrz@handcash.io"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		if _, found, _ := cache.Get(ctx, "mrz@handcash.io"); found {
+			t.Fatalf("%s Failed: expected the entry to be deleted", t.Name())
+		}
+		if err = cache.Delete(ctx, "mrz@handcash.io"); err != nil {
+			t.Fatalf("%s Failed: deleting a missing entry should not fail, error [%s]", t.Name(), err.Error())
+		}
+	})
+
+	t.Run("expired entry is removed", func(t *testing.T) {
+		cache, _ := NewFileCache(t.TempDir())
+		_ = cache.Set(ctx, "mrz@handcash.io", []byte("19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h"), -time.Second)
+		if _, found, err := cache.Get(ctx, "mrz@handcash.io"); err != nil || found {
+			t.Fatalf("%s Failed: expected no entry, error [%v]", t.Name(), err)
+		} else if _, err = os.Stat(cache.path("mrz@handcash.io")); !os.IsNotExist(err) {
+			t.Fatalf("%s Failed: expected the file to be removed", t.Name())
+		}
+	})
+
+	t.Run("no temp files are left behind", func(t *testing.T) {
+		directory := t.TempDir()
+		cache, _ := NewFileCache(directory)
+		_ = cache.Set(ctx, "a", []byte("a"), time.Minute)
+		_ = cache.Set(ctx, "a", []byte("b"), time.Minute)
+		if files, _ := os.ReadDir(directory); len(files) != 1 {
+			t.Fatalf("%s Failed: expected [%d] file, received: [%d]", t.Name(), 1, len(files))
+		}
+	})
+
+	t.Run("corrupt file returns an error once", func(t *testing.T) {
+		cache, _ := NewFileCache(t.TempDir())
+		_ = os.WriteFile(cache.path("a"), []byte("not-json"), 0o600)
+		if _, found, err := cache.Get(ctx, "a"); err == nil || found {
+			t.Fatalf("%s Failed: expected an error", t.Name())
+		} else if _, err = os.Stat(cache.path("a")); !os.IsNotExist(err) {
+			t.Fatalf("%s Failed: expected the corrupt file to be removed", t.Name())
+		} else if _, found, err = cache.Get(ctx, "a"); err != nil || found {
+			t.Fatalf("%s Failed: expected a miss, error [%v]", t.Name(), err)
+		}
+	})
+
+	t.Run("expired, corrupt and stale temp files are swept on start", func(t *testing.T) {
+		directory := t.TempDir()
+		cache, _ := NewFileCache(directory)
+		_ = cache.Set(ctx, "expired", []byte("a"), -time.Second)
+		_ = cache.Set(ctx, "valid", []byte("b"), time.Minute)
+		_ = os.WriteFile(cache.path("corrupt"), []byte("not-json"), 0o600)
+		stale, recent := filepath.Join(directory, ".tmp-stale"), filepath.Join(directory, ".tmp-recent")
+		_ = os.WriteFile(stale, []byte("a"), 0o600)
+		_ = os.WriteFile(recent, []byte("a"), 0o600)
+		old := time.Now().Add(-2 * fileCacheTempMaxAge)
+		_ = os.Chtimes(stale, old, old)
+
+		if _, err := NewFileCache(directory); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		for _, name := range []string{cache.path("expired"), cache.path("corrupt"), stale} {
+			if _, err := os.Stat(name); !os.IsNotExist(err) {
+				t.Errorf("%s Failed: expected [%s] to be removed", t.Name(), filepath.Base(name))
+			}
+		}
+		for _, name := range []string{cache.path("valid"), recent} {
+			if _, err := os.Stat(name); err != nil {
+				t.Errorf("%s Failed: expected [%s] to be kept, error [%s]", t.Name(), filepath.Base(name), err.Error())
+			}
+		}
+	})
+
+	t.Run("expired files are swept while writing", func(t *testing.T) {
+		directory := t.TempDir()
+		cache, _ := NewFileCache(directory)
+		for i := 0; i < fileCacheSweepInterval; i++ {
+			_ = cache.Set(ctx, fmt.Sprintf("expired-%d", i), []byte("a"), -time.Second)
+		}
+		if files, _ := os.ReadDir(directory); len(files) != 1 {
+			t.Fatalf("%s Failed: expected [%d] file, received: [%d]", t.Name(), 1, len(files))
+		}
+	})
+}
+
+// TestGetAddress_FileCache will make sure a new client with the same file cache comes back warm
+func TestGetAddress_FileCache(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+	for i := 0; i < 2; i++ {
+		cache, err := NewFileCache(directory)
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		mock := &mockHTTPCounter{}
+		client := &Client{cache: cache, cacheTTL: time.Minute, httpClient: mock, UserAgent: defaultUserAgent}
+
+		output, err := client.GetAddress("1mrz")
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if output.Address != "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa" {
+			t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa", output.Address)
+		} else if output.CacheHit != (i == 1) {
+			t.Fatalf("%s Failed: unexpected cache hit [%v] on client [%d]", t.Name(), output.CacheHit, i)
+		} else if mock.count() != int64(1-i) {
+			t.Fatalf("%s Failed: expected [%d] requests, received: [%d]", t.Name(), 1-i, mock.count())
+		}
+	}
+}
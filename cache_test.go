@@ -0,0 +1,199 @@
+package polynym
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newMockCacheClient will create a new mock client with the cache enabled
+func newMockCacheClient(ttl, negativeTTL time.Duration, maxEntries int) (*Client, *mockHTTPCounter) {
+	mock := &mockHTTPCounter{}
+	return &Client{
+		cache:            NewMemoryCache(maxEntries),
+		cacheNegativeTTL: negativeTTL,
+		cacheTTL:         ttl,
+		httpClient:       mock,
+		UserAgent:        defaultUserAgent,
+	}, mock
+}
+
+// TestMemoryCache will test the MemoryCache get/set/delete, expiration and eviction
+func TestMemoryCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("get, set and delete", func(t *testing.T) {
+		cache := NewMemoryCache(10)
+		if _, found, err := cache.Get(ctx, "mrz@handcash.io"); err != nil || found {
+			t.Fatalf("%s Failed: expected no entry", t.Name())
+		}
+		_ = cache.Set(ctx, "mrz@handcash.io", []byte("19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h"), time.Minute)
+		if value, found, err := cache.Get(ctx, "mrz@handcash.io"); err != nil || !found || string(value) != "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h" {
+			t.Fatalf("%s Failed: expected the entry, received: [%s]", t.Name(), value)
+		}
+		_ = cache.Delete(ctx, "mrz@handcash.io")
+		if _, found, _ := cache.Get(ctx, "mrz@handcash.io"); found {
+			t.Fatalf("%s Failed: expected the entry to be deleted", t.Name())
+		}
+	})
+
+	t.Run("expired entry", func(t *testing.T) {
+		cache := NewMemoryCache(10)
+		_ = cache.Set(ctx, "mrz@handcash.io", []byte("19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h"), -time.Second)
+		if _, found, _ := cache.Get(ctx, "mrz@handcash.io"); found {
+			t.Fatalf("%s Failed: expected no entry", t.Name())
+		} else if cache.Len() != 0 {
+			t.Fatalf("%s Failed: expected the entry to be removed", t.Name())
+		}
+	})
+
+	t.Run("least recently used is evicted", func(t *testing.T) {
+		cache := NewMemoryCache(2)
+		_ = cache.Set(ctx, "a", []byte("a"), time.Minute)
+		_ = cache.Set(ctx, "b", []byte("b"), time.Minute)
+		_, _, _ = cache.Get(ctx, "a")
+		_ = cache.Set(ctx, "c", []byte("c"), time.Minute)
+		if cache.Len() != 2 {
+			t.Fatalf("%s Failed: expected [%d] entries, received: [%d]", t.Name(), 2, cache.Len())
+		} else if _, found, _ := cache.Get(ctx, "b"); found {
+			t.Fatalf("%s Failed: expected [b] to be evicted", t.Name())
+		} else if _, found, _ = cache.Get(ctx, "a"); !found {
+			t.Fatalf("%s Failed: expected [a] to remain", t.Name())
+		} else if _, found, _ = cache.Get(ctx, "c"); !found {
+			t.Fatalf("%s Failed: expected [c] to remain", t.Name())
+		}
+	})
+}
+
+// TestGetAddress_Cache will test caching resolved addresses in GetAddress()
+func TestGetAddress_Cache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("canonical identifiers share an entry", func(t *testing.T) {
+		client, mock := newMockCacheClient(time.Minute, time.Minute, 10)
+		for _, input := range []string{"$MrZ", "mrz@handcash.io", "MRZ@handcash.io"} {
+			output, err := client.GetAddress(input)
+			if err != nil {
+				t.Fatalf("%s Failed: [%s] inputted, received error [%s]", t.Name(), input, err.Error())
+			} else if output.Address != "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h" {
+				t.Fatalf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), input, "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", output.Address)
+			} else if output.CacheHit != (input != "$MrZ") {
+				t.Fatalf("%s Failed: [%s] inputted and unexpected cache hit [%v]", t.Name(), input, output.CacheHit)
+			} else if output.LastRequest.StatusCode != http.StatusOK {
+				t.Fatalf("%s Failed: [%s] inputted and [%d] expected, received: [%d]", t.Name(), input, http.StatusOK, output.LastRequest.StatusCode)
+			}
+		}
+		if mock.count() != 1 {
+			t.Fatalf("%s Failed: expected [%d] requests, received: [%d]", t.Name(), 1, mock.count())
+		}
+	})
+
+	t.Run("not found results use the negative ttl", func(t *testing.T) {
+		client, mock := newMockCacheClient(time.Minute, time.Minute, 10)
+		for i := 0; i < 2; i++ {
+			output, err := client.GetAddress("bad@paymailaddress.com")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrNotFound, err)
+			} else if output.CacheHit != (i == 1) {
+				t.Fatalf("%s Failed: unexpected cache hit [%v]", t.Name(), output.CacheHit)
+			} else if output.ErrorMessage != "PayMail not found" {
+				t.Fatalf("%s Failed: unexpected error message [%s]", t.Name(), output.ErrorMessage)
+			}
+		}
+		if mock.count() != 1 {
+			t.Fatalf("%s Failed: expected [%d] requests, received: [%d]", t.Name(), 1, mock.count())
+		}
+	})
+
+	t.Run("not found results are not cached without a negative ttl", func(t *testing.T) {
+		client, mock := newMockCacheClient(time.Minute, 0, 10)
+		for i := 0; i < 2; i++ {
+			if _, err := client.GetAddress("bad@paymailaddress.com"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrNotFound, err)
+			}
+		}
+		if mock.count() != 2 {
+			t.Fatalf("%s Failed: expected [%d] requests, received: [%d]", t.Name(), 2, mock.count())
+		}
+	})
+
+	t.Run("other errors are not cached", func(t *testing.T) {
+		client, mock := newMockCacheClient(time.Minute, time.Minute, 10)
+		for i := 0; i < 2; i++ {
+			if _, err := client.GetAddress("error@test.com"); !errors.Is(err, ErrTransportFailure) {
+				t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrTransportFailure, err)
+			}
+		}
+		if mock.count() != 2 {
+			t.Fatalf("%s Failed: expected [%d] requests, received: [%d]", t.Name(), 2, mock.count())
+		}
+	})
+
+	t.Run("cache errors are treated as a miss", func(t *testing.T) {
+		client, mock := newMockCacheClient(time.Minute, time.Minute, 10)
+		client.cache = &mockCacheError{}
+		for i := 0; i < 2; i++ {
+			if output, err := client.GetAddress("1mrz"); err != nil {
+				t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+			} else if output.CacheHit {
+				t.Fatalf("%s Failed: expected no cache hit", t.Name())
+			}
+		}
+		if mock.count() != 2 {
+			t.Fatalf("%s Failed: expected [%d] requests, received: [%d]", t.Name(), 2, mock.count())
+		}
+	})
+
+	t.Run("expired entries are resolved again", func(t *testing.T) {
+		client, mock := newMockCacheClient(time.Millisecond, 0, 10)
+		_, _ = client.GetAddress("1mrz")
+		time.Sleep(5 * time.Millisecond)
+		if output, err := client.GetAddress("1mrz"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if output.CacheHit {
+			t.Fatalf("%s Failed: expected no cache hit", t.Name())
+		}
+		if mock.count() != 2 {
+			t.Fatalf("%s Failed: expected [%d] requests, received: [%d]", t.Name(), 2, mock.count())
+		}
+	})
+}
+
+// TestNewClient_Cache tests enabling the cache via options
+func TestNewClient_Cache(t *testing.T) {
+	t.Parallel()
+
+	if client := NewClient(nil); client.cache != nil {
+		t.Fatalf("%s Failed: expected the cache to be disabled by default", t.Name())
+	}
+
+	options := ClientDefaultOptions()
+	options.CacheTTL = time.Minute
+	options.CacheNegativeTTL = 10 * time.Second
+	client := NewClient(options)
+	if client.cache == nil {
+		t.Fatalf("%s Failed: expected the cache to be enabled", t.Name())
+	} else if memoryCache, ok := client.cache.(*MemoryCache); !ok || memoryCache.maxEntries != options.CacheMaxEntries {
+		t.Fatalf("%s Failed: expected a memory cache with [%d] max entries", t.Name(), options.CacheMaxEntries)
+	}
+
+	options.Cache = NewMemoryCache(1)
+	if client = NewClient(options); client.cache != options.Cache {
+		t.Fatalf("%s Failed: expected the cache from the options", t.Name())
+	}
+}
+
+// ExampleOptions_cache example enabling the cache
+func ExampleOptions_cache() {
+	options := ClientDefaultOptions()
+	options.CacheTTL = 10 * time.Minute
+	options.CacheNegativeTTL = time.Minute
+	client := NewClient(options)
+	fmt.Println(client.cache != nil)
+	// Output:true
+}
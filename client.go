@@ -1,8 +1,10 @@
 package polynym
 
 import (
+	"context"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gojektech/heimdall/v6"
@@ -17,8 +19,30 @@ const (
 	// defaultUserAgent is the default user agent for all requests
 	defaultUserAgent string = "go-polynym: " + version
 
-	// apiEndpoint is where we fire requests
+	// apiEndpoint is where we fire requests (default)
 	apiEndpoint string = "https://api.polynym.io"
+
+	// localAPIEndpoint is the default address of a locally running Polynym instance
+	localAPIEndpoint string = "http://localhost:3000"
+)
+
+// Environment is a preset for the API endpoint and the HandCash network
+type Environment struct {
+	APIEndpoint  string `json:"api_endpoint"`  // APIEndpoint is the base URL of the Polynym API
+	HandCashBeta bool   `json:"handcash_beta"` // HandCashBeta will convert $handles to the beta HandCash paymails
+	Name         string `json:"name"`          // Name is the name of the environment
+}
+
+// Environment presets
+var (
+	// EnvironmentProduction is the public Polynym API with production HandCash handles
+	EnvironmentProduction = Environment{APIEndpoint: apiEndpoint, Name: "production"}
+
+	// EnvironmentBeta is the public Polynym API with beta HandCash handles
+	EnvironmentBeta = Environment{APIEndpoint: apiEndpoint, HandCashBeta: true, Name: "beta"}
+
+	// EnvironmentLocal is a locally running Polynym instance with production HandCash handles
+	EnvironmentLocal = Environment{APIEndpoint: localAPIEndpoint, Name: "local"}
 )
 
 // httpInterface is used for the http client (mocking heimdall)
@@ -28,42 +52,80 @@ type httpInterface interface {
 
 // Client is the parent struct that wraps the heimdall client
 type Client struct {
-	httpClient httpInterface // carries out the http operations (heimdall client)
-	UserAgent  string        // (optional for changing user agents)
+	apiEndpoint      string             // base URL of the Polynym API
+	cache            Cache              // cache of resolved addresses (nil if disabled)
+	cacheNegativeTTL time.Duration      // how long to cache not found results
+	cacheTTL         time.Duration      // how long to cache resolved addresses
+	dnsResolver      DNSResolver        // resolver for the paymail SRV records
+	flights          flightGroup        // concurrent lookups of the same identifier
+	handCashBeta     bool               // convert $handles to the beta HandCash paymails
+	handles          *handleRegistry    // handle providers (built-in providers if nil)
+	httpClient       httpInterface      // carries out the http operations (heimdall client)
+	logPrivacyKey    []byte             // key of the identifier hashes in the logs (random per process if not set)
+	logPrivacyMode   bool               // hash the identifiers in the logs (instead of redacting them)
+	logger           Logger             // receives the structured logs (nil if disabled)
+	observer         Observer           // receives the metrics of every lookup and request (nil if disabled)
+	paymailFallback  bool               // resolve paymails natively when Polynym fails (requires the sender handle)
+	paymailSender    string             // sender handle used for the paymail address resolution
+	paymailTrustSRV  bool               // accept SRV targets outside of the paymail domain
+	retrier          heimdall.Retriable // calculates the back-off between retries
+	retryCount       int                // number of retries after the first attempt
+	tracer           Tracer             // starts the spans of every lookup and request (nil if disabled)
+	UserAgent        string             // (optional for changing user agents)
 }
 
 // Options holds all the configuration for connection, dialer and transport
 type Options struct {
-	BackOffExponentFactor          float64       `json:"back_off_exponent_factor"`
-	BackOffInitialTimeout          time.Duration `json:"back_off_initial_timeout"`
-	BackOffMaximumJitterInterval   time.Duration `json:"back_off_maximum_jitter_interval"`
-	BackOffMaxTimeout              time.Duration `json:"back_off_max_timeout"`
-	DialerKeepAlive                time.Duration `json:"dialer_keep_alive"`
-	DialerTimeout                  time.Duration `json:"dialer_timeout"`
-	RequestRetryCount              int           `json:"request_retry_count"`
-	RequestTimeout                 time.Duration `json:"request_timeout"`
-	TransportExpectContinueTimeout time.Duration `json:"transport_expect_continue_timeout"`
-	TransportIdleTimeout           time.Duration `json:"transport_idle_timeout"`
-	TransportMaxIdleConnections    int           `json:"transport_max_idle_connections"`
-	TransportTLSHandshakeTimeout   time.Duration `json:"transport_tls_handshake_timeout"`
-	UserAgent                      string        `json:"user_agent"`
+	APIEndpoint                    string           `json:"api_endpoint"`
+	BackOffExponentFactor          float64          `json:"back_off_exponent_factor"`
+	BackOffInitialTimeout          time.Duration    `json:"back_off_initial_timeout"`
+	BackOffMaximumJitterInterval   time.Duration    `json:"back_off_maximum_jitter_interval"`
+	BackOffMaxTimeout              time.Duration    `json:"back_off_max_timeout"`
+	Cache                          Cache            `json:"-"`
+	CacheMaxEntries                int              `json:"cache_max_entries"`
+	CacheNegativeTTL               time.Duration    `json:"cache_negative_ttl"`
+	CacheTTL                       time.Duration    `json:"cache_ttl"`
+	DialerKeepAlive                time.Duration    `json:"dialer_keep_alive"`
+	DialerTimeout                  time.Duration    `json:"dialer_timeout"`
+	DNSResolver                    DNSResolver      `json:"-"`
+	HandCashBeta                   bool             `json:"handcash_beta"`
+	HandleProviders                []HandleProvider `json:"-"`
+	Logger                         Logger           `json:"-"`
+	LogPrivacyKey                  []byte           `json:"-"`
+	LogPrivacyMode                 bool             `json:"log_privacy_mode"`
+	Observer                       Observer         `json:"-"`
+	PaymailFallback                bool             `json:"paymail_fallback"`
+	PaymailSenderHandle            string           `json:"paymail_sender_handle"`
+	PaymailTrustSRVTarget          bool             `json:"paymail_trust_srv_target"`
+	RequestRetryCount              int              `json:"request_retry_count"`
+	RequestTimeout                 time.Duration    `json:"request_timeout"`
+	Tracer                         Tracer           `json:"-"`
+	TransportExpectContinueTimeout time.Duration    `json:"transport_expect_continue_timeout"`
+	TransportIdleTimeout           time.Duration    `json:"transport_idle_timeout"`
+	TransportMaxIdleConnections    int              `json:"transport_max_idle_connections"`
+	TransportTLSHandshakeTimeout   time.Duration    `json:"transport_tls_handshake_timeout"`
+	UserAgent                      string           `json:"user_agent"`
 }
 
 // LastRequest is used to track what was submitted via the Request
 type LastRequest struct {
-	Method     string `json:"method"`      // Method is the HTTP method used
-	StatusCode int    `json:"status_code"` // StatusCode is the last code from the request
-	URL        string `json:"url"`         // URL is the url used for the request
+	Attempts      int             `json:"attempts"`                 // Attempts is the number of times the request was sent (1 + retries)
+	AttemptTraces []*AttemptTrace `json:"attempt_traces,omitempty"` // AttemptTraces are the timings of every attempt (in order)
+	Method        string          `json:"method"`                   // Method is the HTTP method used
+	StatusCode    int             `json:"status_code"`              // StatusCode is the last code from the request
+	URL           string          `json:"url"`                      // URL is the url used for the request
 }
 
 // ClientDefaultOptions will return an clientOptions struct with the default settings
 // Useful for starting with the base defaults and then modifying as needed
 func ClientDefaultOptions() (clientOptions *Options) {
 	return &Options{
+		APIEndpoint:                    apiEndpoint,
 		BackOffExponentFactor:          2.0,
 		BackOffInitialTimeout:          2 * time.Millisecond,
 		BackOffMaximumJitterInterval:   2 * time.Millisecond,
 		BackOffMaxTimeout:              10 * time.Millisecond,
+		CacheMaxEntries:                1000,
 		DialerKeepAlive:                20 * time.Second,
 		DialerTimeout:                  5 * time.Second,
 		RequestRetryCount:              2,
@@ -76,11 +138,17 @@ func ClientDefaultOptions() (clientOptions *Options) {
 	}
 }
 
+// SetEnvironment will set the API endpoint and HandCash network from an environment preset
+func (o *Options) SetEnvironment(environment Environment) {
+	o.APIEndpoint = environment.APIEndpoint
+	o.HandCashBeta = environment.HandCashBeta
+}
+
 // NewClient will make a new http client based on the options provided
-func NewClient(options *Options) (c Client) {
+func NewClient(options *Options) (c *Client) {
 
 	// Create a client
-	c = Client{}
+	c = &Client{}
 
 	// Set options (either default or user modified)
 	if options == nil {
@@ -90,6 +158,38 @@ func NewClient(options *Options) (c Client) {
 	// Set the user agent from options
 	c.UserAgent = options.UserAgent
 
+	// Set the API endpoint and HandCash network from options
+	c.apiEndpoint = strings.TrimSuffix(options.APIEndpoint, "/")
+	c.handCashBeta = options.HandCashBeta
+
+	// Set the custom handle providers (checked before the built-in providers)
+	if len(options.HandleProviders) > 0 {
+		c.handles = newHandleRegistry(options.HandleProviders...)
+	}
+
+	// Set the paymail options (native resolution and fallback)
+	c.dnsResolver = options.DNSResolver
+	c.paymailFallback = options.PaymailFallback
+	c.paymailSender = options.PaymailSenderHandle
+	c.paymailTrustSRV = options.PaymailTrustSRVTarget
+
+	// Set the observer for the metrics, the tracer for the spans and the logger (optional)
+	c.logger = options.Logger
+	c.logPrivacyKey = append([]byte(nil), options.LogPrivacyKey...)
+	c.logPrivacyMode = options.LogPrivacyMode
+	c.observer = options.Observer
+	c.tracer = options.Tracer
+
+	// Enable the cache (opt-in by setting a TTL, in-memory unless a cache is provided)
+	if options.CacheTTL > 0 {
+		c.cache = options.Cache
+		if c.cache == nil {
+			c.cache = NewMemoryCache(options.CacheMaxEntries)
+		}
+		c.cacheNegativeTTL = options.CacheNegativeTTL
+		c.cacheTTL = options.CacheTTL
+	}
+
 	// dial is the net dialer for clientDefaultTransport
 	dial := &net.Dialer{KeepAlive: options.DialerKeepAlive, Timeout: options.DialerTimeout}
 
@@ -103,34 +203,148 @@ func NewClient(options *Options) (c Client) {
 		TLSHandshakeTimeout:   options.TransportTLSHandshakeTimeout,
 	}
 
-	// Determine the strategy for the http client (no retry enabled)
-	if options.RequestRetryCount == 0 {
-		c.httpClient = httpclient.NewClient(
-			httpclient.WithHTTPTimeout(options.RequestTimeout),
-			httpclient.WithHTTPClient(&http.Client{
-				Transport: clientDefaultTransport,
-				Timeout:   options.RequestTimeout,
-			}),
-		)
+	// Create the http client (retries are handled by the client, so they can honor the request context)
+	c.httpClient = httpclient.NewClient(
+		httpclient.WithHTTPTimeout(options.RequestTimeout),
+		httpclient.WithHTTPClient(&keepAliveDoer{client: &http.Client{
+			Transport: clientDefaultTransport,
+			Timeout:   options.RequestTimeout,
+		}}),
+	)
+
+	// Determine the strategy for the retries (no retry enabled)
+	if options.RequestRetryCount <= 0 {
+		c.retrier = heimdall.NewNoRetrier()
 	} else { // Retry enabled
 		// Create exponential back-off
-		backOff := heimdall.NewExponentialBackoff(
+		c.retrier = heimdall.NewRetrier(heimdall.NewExponentialBackoff(
 			options.BackOffInitialTimeout,
 			options.BackOffMaxTimeout,
 			options.BackOffExponentFactor,
 			options.BackOffMaximumJitterInterval,
-		)
-
-		c.httpClient = httpclient.NewClient(
-			httpclient.WithHTTPTimeout(options.RequestTimeout),
-			httpclient.WithRetrier(heimdall.NewRetrier(backOff)),
-			httpclient.WithRetryCount(options.RequestRetryCount),
-			httpclient.WithHTTPClient(&http.Client{
-				Transport: clientDefaultTransport,
-				Timeout:   options.RequestTimeout,
-			}),
-		)
+		))
+		c.retryCount = options.RequestRetryCount
 	}
 
 	return
 }
+
+// keepAliveDoer sends the requests of the heimdall client, which closes the connection after every
+// request (request.Close), so the idle connections of the transport are reused
+//
+// The heimdall client also replaces a transport error with its text (which includes the URL), so the
+// original error is kept for the attempt (see transportErrorKey)
+type keepAliveDoer struct {
+	client *http.Client
+}
+
+// transportErrorKey is the context key of the original transport error of an attempt (a *error)
+type transportErrorKey struct{}
+
+// Do will send the request (keeping the connection open)
+func (k *keepAliveDoer) Do(req *http.Request) (*http.Response, error) {
+	req.Close = false
+	resp, err := k.client.Do(req)
+	if original, ok := req.Context().Value(transportErrorKey{}).(*error); ok && err != nil {
+		*original = err
+	}
+	return resp, err
+}
+
+// endpoint returns the base URL of the Polynym API (default if not set)
+func (c *Client) endpoint() string {
+	if len(c.apiEndpoint) == 0 {
+		return apiEndpoint
+	}
+	return c.apiEndpoint
+}
+
+// doRequest fires the request, retrying on transport errors and 5xx responses (up to the retry count)
+//
+// The context of the request is checked before every attempt and during every back-off wait,
+// if it is canceled or its deadline is exceeded, the context error is returned. Every attempt
+// is traced on the last request and reported to the observer, the tracer and the logger (if set).
+func (c *Client) doRequest(req *http.Request, lastRequest *LastRequest, retryCount int) (resp *http.Response, err error) {
+	ctx := req.Context()
+	for attempt := 0; attempt <= retryCount; attempt++ {
+
+		// Wait for the back-off (or the context)
+		var backOff time.Duration
+		if attempt > 0 {
+			if resp != nil && resp.Body != nil {
+				_ = resp.Body.Close()
+			}
+			c.logRetry(ctx, req, attempt+1, resp, err)
+			waitStart := time.Now()
+			if err = waitForRetry(ctx, c.retrier, attempt-1); err != nil {
+				return nil, err
+			}
+			backOff = time.Since(waitStart)
+			if req.GetBody != nil {
+				if req.Body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// Fire the request (if the context is still active)
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		lastRequest.Attempts++
+		if resp, err = c.doAttempt(req, lastRequest, backOff); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			continue
+		} else if resp.StatusCode >= http.StatusInternalServerError {
+			continue
+		}
+		return resp, nil
+	}
+	return resp, err
+}
+
+// doAttempt fires a single attempt of the request, the trace of the attempt is added to the last request
+func (c *Client) doAttempt(req *http.Request, lastRequest *LastRequest, backOff time.Duration) (*http.Response, error) {
+	ctx, span := c.startSpan(req.Context(), SpanRequest)
+	c.log(ctx, LogLevelDebug, "request started", map[string]interface{}{
+		"attempt":  lastRequest.Attempts,
+		"host":     req.URL.Host,
+		"method":   req.Method,
+		"upstream": c.upstream(req),
+	})
+	tracer := newAttemptTracer(lastRequest.Attempts, backOff)
+	var transportErr error
+	resp, err := c.httpClient.Do(req.WithContext(context.WithValue(tracer.withContext(ctx), transportErrorKey{}, &transportErr)))
+	if err != nil && transportErr != nil {
+		err = transportErr
+	}
+	trace := tracer.finish(resp, err)
+	lastRequest.AttemptTraces = append(lastRequest.AttemptTraces, trace)
+
+	// Report the attempt
+	event := c.newRequestEvent(req, trace, err)
+	if c.observer != nil {
+		c.observer.ObserveRequest(event)
+	}
+	c.logRequest(ctx, event)
+	endRequestSpan(span, event)
+	return resp, err
+}
+
+// waitForRetry will sleep for the back-off interval or return early if the context is done
+func waitForRetry(ctx context.Context, retrier heimdall.Retriable, retry int) error {
+	var interval time.Duration
+	if retrier != nil {
+		interval = retrier.NextInterval(retry)
+	}
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
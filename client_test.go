@@ -1,9 +1,13 @@
 package polynym
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
+
+	"github.com/gojektech/heimdall/v6"
 )
 
 // TestNewClient test new client
@@ -41,6 +45,14 @@ func TestClientDefaultOptions(t *testing.T) {
 		t.Fatalf("expected value: %s got: %s", defaultUserAgent, options.UserAgent)
 	}
 
+	if options.APIEndpoint != apiEndpoint {
+		t.Fatalf("expected value: %s got: %s", apiEndpoint, options.APIEndpoint)
+	}
+
+	if options.HandCashBeta {
+		t.Fatalf("expected value: %v got: %v", false, options.HandCashBeta)
+	}
+
 	if options.BackOffExponentFactor != 2.0 {
 		t.Fatalf("expected value: %f got: %f", 2.0, options.BackOffExponentFactor)
 	}
@@ -57,6 +69,14 @@ func TestClientDefaultOptions(t *testing.T) {
 		t.Fatalf("expected value: %v got: %v", 10*time.Millisecond, options.BackOffMaxTimeout)
 	}
 
+	if options.CacheMaxEntries != 1000 {
+		t.Fatalf("expected value: %v got: %v", 1000, options.CacheMaxEntries)
+	}
+
+	if options.CacheTTL != 0 {
+		t.Fatalf("expected value: %v got: %v", 0, options.CacheTTL)
+	}
+
 	if options.DialerKeepAlive != 20*time.Second {
 		t.Fatalf("expected value: %v got: %v", 20*time.Second, options.DialerKeepAlive)
 	}
@@ -100,3 +120,63 @@ func TestClientDefaultOptions_NoRetry(t *testing.T) {
 		t.Errorf("user agent mismatch")
 	}
 }
+
+// TestNewClient_Environment tests creating a client with environment presets
+func TestNewClient_Environment(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		environment      Environment
+		expectedEndpoint string
+		expectedBeta     bool
+	}{
+		{EnvironmentProduction, apiEndpoint, false},
+		{EnvironmentBeta, apiEndpoint, true},
+		{EnvironmentLocal, localAPIEndpoint, false},
+		{Environment{APIEndpoint: "https://polynym.example.com/"}, "https://polynym.example.com", false},
+	}
+
+	// Test all
+	for _, test := range tests {
+		options := ClientDefaultOptions()
+		options.SetEnvironment(test.environment)
+		client := NewClient(options)
+		if client.endpoint() != test.expectedEndpoint {
+			t.Errorf("%s Failed: [%s] environment and [%s] expected, received: [%s]", t.Name(), test.environment.Name, test.expectedEndpoint, client.endpoint())
+		} else if client.handCashBeta != test.expectedBeta {
+			t.Errorf("%s Failed: [%s] environment and [%v] expected, received: [%v]", t.Name(), test.environment.Name, test.expectedBeta, client.handCashBeta)
+		}
+	}
+}
+
+// TestDoRequest_Retries will retry a failing upstream until the retry count is reached
+func TestDoRequest_Retries(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockHTTPUnavailable{}
+	client := &Client{
+		httpClient: mock,
+		retrier:    heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Millisecond, 0)),
+		retryCount: 2,
+		UserAgent:  defaultUserAgent,
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, apiEndpoint+"/getAddress/1mrz", nil)
+	if err != nil {
+		t.Fatalf("failed creating request: %s", err.Error())
+	}
+
+	var resp *http.Response
+	lastRequest := &LastRequest{}
+	if resp, err = client.doRequest(req, lastRequest, client.retryCount); err != nil {
+		t.Fatalf("expected no error, got: %s", err.Error())
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected status: %d got: %d", http.StatusServiceUnavailable, resp.StatusCode)
+	} else if mock.attempts != 3 || lastRequest.Attempts != 3 {
+		t.Fatalf("expected attempts: %d got: %d (last request: %d)", 3, mock.attempts, lastRequest.Attempts)
+	}
+}
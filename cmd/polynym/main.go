@@ -0,0 +1,110 @@
+/*
+Package main is the polynym command line tool for resolving handles, paymails and addresses
+
+Example:
+
+	polynym resolve 1mrz '$mr-z' mrz@handcash.io
+	polynym resolve -output json -file handles.txt
+	cat handles.txt | polynym resolve -output csv
+	polynym serve -addr :3000 -cache-ttl 1h -paymail-fallback -paymail-sender-handle ops@example.com
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/mrz1836/go-polynym"
+)
+
+// Exit codes (the exit code of a batch is the code of the first failed input)
+const (
+	exitOK               = 0 // All inputs were resolved
+	exitError            = 1 // Unexpected error (reading the inputs, writing the output, etc)
+	exitUsage            = 2 // Invalid command or flags
+	exitInvalidInput     = 3 // polynym.ErrInvalidInput
+	exitNotFound         = 4 // polynym.ErrNotFound
+	exitRateLimited      = 5 // polynym.ErrRateLimited
+	exitUpstream         = 6 // polynym.ErrUpstreamUnavailable
+	exitDecodeFailure    = 7 // polynym.ErrDecodeFailure
+	exitTransportFailure = 8 // polynym.ErrTransportFailure
+	exitTimeout          = 9 // The timeout passed or the command was interrupted
+)
+
+// usage is the help for the command
+const usage = `Usage: polynym <command> [flags] [arguments]
+
+Commands:
+  resolve   Resolve handles, paymails, Twetch user ids or addresses
+  serve     Serve the Polynym API (GET /getAddress/{id}) using the client options
+  help      Show this help
+
+Run "polynym <command> -h" for the flags of a command.
+
+Exit codes:
+  0  all inputs were resolved
+  1  unexpected error
+  2  invalid command or flags
+  3  invalid input
+  4  not found
+  5  rate limited
+  6  upstream unavailable
+  7  failed to decode the response
+  8  failed to complete the request
+  9  timeout or interrupted
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run will run the command and return the exit code
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "resolve":
+		return runResolve(ctx, args[1:], stdin, stdout, stderr)
+	case "serve":
+		return runServe(ctx, args[1:], stderr)
+	case "help", "-h", "-help", "--help":
+		_, _ = fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+
+	_, _ = fmt.Fprintf(stderr, "unknown command: %s\n\n%s", args[0], usage)
+	return exitUsage
+}
+
+// exitCode returns the exit code for the class of the error
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return exitTimeout
+	case errors.Is(err, polynym.ErrInvalidInput):
+		return exitInvalidInput
+	case errors.Is(err, polynym.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, polynym.ErrRateLimited):
+		return exitRateLimited
+	case errors.Is(err, polynym.ErrUpstreamUnavailable):
+		return exitUpstream
+	case errors.Is(err, polynym.ErrDecodeFailure):
+		return exitDecodeFailure
+	case errors.Is(err, polynym.ErrTransportFailure):
+		return exitTransportFailure
+	}
+	return exitError
+}
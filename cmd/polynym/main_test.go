@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mrz1836/go-polynym"
+)
+
+// newMockPolynym returns a server with the Polynym /getAddress/{id} contract
+func newMockPolynym(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch strings.TrimPrefix(req.URL.Path, "/getAddress/") {
+		case "mrz@relayx.io":
+			_, _ = fmt.Fprint(w, `{"address":"1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa"}`)
+		case "mr-z@handcash.io":
+			_, _ = fmt.Fprint(w, `{"address":"124dwBFyFtkcNXGfVWQroGcT9ybnpQ3G3Z"}`)
+		case "rate@limited.com":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"error":"PayMail not found"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// runCommand will run the command and return the exit code, stdout and stderr
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// TestRun will test the run() method
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		args         []string
+		expectedCode int
+		expectedOut  string
+	}{
+		{nil, exitUsage, ""},
+		{[]string{"help"}, exitOK, "Usage: polynym"},
+		{[]string{"-h"}, exitOK, "Usage: polynym"},
+		{[]string{"unknown"}, exitUsage, ""},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if code, stdout, _ := runCommand("", test.args...); code != test.expectedCode {
+			t.Errorf("%s Failed: %v inputted and [%d] expected, received: [%d]", t.Name(), test.args, test.expectedCode, code)
+		} else if !strings.Contains(stdout, test.expectedOut) {
+			t.Errorf("%s Failed: %v inputted and [%s] expected, received: [%s]", t.Name(), test.args, test.expectedOut, stdout)
+		}
+	}
+}
+
+// TestRunResolve will test the resolve command
+func TestRunResolve(t *testing.T) {
+	t.Parallel()
+
+	server := newMockPolynym(t)
+	endpoint := "-api-endpoint=" + server.URL
+
+	t.Run("text output", func(t *testing.T) {
+		code, stdout, stderr := runCommand("", "resolve", endpoint, "1mrz", "$mr-z", "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h")
+		expected := "1mrz\t1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa\n" +
+			"$mr-z\t124dwBFyFtkcNXGfVWQroGcT9ybnpQ3G3Z\n" +
+			"19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h\t19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h\n"
+		if code != exitOK || stdout != expected || len(stderr) > 0 {
+			t.Fatalf("%s Failed: unexpected result [%d] [%s] [%s]", t.Name(), code, stdout, stderr)
+		}
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		code, stdout, stderr := runCommand("", "resolve", endpoint, "-log-level", "info", "1mrz")
+		if code != exitOK || stdout != "1mrz\t1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa\n" {
+			t.Fatalf("%s Failed: unexpected result [%d] [%s]", t.Name(), code, stdout)
+		} else if !strings.Contains(stderr, `"message":"lookup finished"`) || !strings.Contains(stderr, `"identifier":"m***@relayx.io"`) {
+			t.Fatalf("%s Failed: unexpected logs [%s]", t.Name(), stderr)
+		} else if strings.Contains(stderr, "mrz@relayx.io") || strings.Contains(stderr, `"level":"debug"`) {
+			t.Fatalf("%s Failed: the identifier or debug entries should not be logged [%s]", t.Name(), stderr)
+		}
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		code, stdout, stderr := runCommand("", "resolve", endpoint, "1mrz", "unknown@handcash.io", "bad input")
+		if code != exitNotFound {
+			t.Fatalf("%s Failed: expected [%d] received: [%d]", t.Name(), exitNotFound, code)
+		} else if stdout != "1mrz\t1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa\n" {
+			t.Fatalf("%s Failed: unexpected output [%s]", t.Name(), stdout)
+		} else if !strings.Contains(stderr, "unknown@handcash.io: ") || !strings.Contains(stderr, "bad input: ") {
+			t.Fatalf("%s Failed: unexpected errors [%s]", t.Name(), stderr)
+		}
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		code, stdout, _ := runCommand("", "resolve", endpoint, "-output", "JSON", "1mrz", "rate@limited.com")
+		var results []*resolveResult
+		if code != exitRateLimited {
+			t.Fatalf("%s Failed: expected [%d] received: [%d]", t.Name(), exitRateLimited, code)
+		} else if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if len(results) != 2 {
+			t.Fatalf("%s Failed: expected [%d] results, received: [%d]", t.Name(), 2, len(results))
+		} else if results[0].Address != "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa" || results[0].Type != "relayx" ||
+			results[0].StatusCode != http.StatusOK {
+			t.Fatalf("%s Failed: unexpected result [%v]", t.Name(), results[0])
+		} else if len(results[1].Error) == 0 || results[1].Type != "paymail" || results[1].StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("%s Failed: unexpected result [%v]", t.Name(), results[1])
+		}
+	})
+
+	t.Run("csv output from stdin", func(t *testing.T) {
+		code, stdout, _ := runCommand("# handles\n1mrz\n\n  $mr-z  \n", "resolve", endpoint, "-output", "csv")
+		expected := "input,type,address,error,status_code,cache_hit\n" +
+			"1mrz,relayx,1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa,,200,false\n" +
+			"$mr-z,handcash,124dwBFyFtkcNXGfVWQroGcT9ybnpQ3G3Z,,200,false\n"
+		if code != exitOK || stdout != expected {
+			t.Fatalf("%s Failed: unexpected result [%d] [%s]", t.Name(), code, stdout)
+		}
+	})
+
+	t.Run("inputs from a file", func(t *testing.T) {
+		file := t.TempDir() + "/handles.txt"
+		if err := writeFile(file, "1mrz\n"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		code, stdout, _ := runCommand("", "resolve", endpoint, "-file", file, "$mr-z")
+		if code != exitOK || stdout != "$mr-z\t124dwBFyFtkcNXGfVWQroGcT9ybnpQ3G3Z\n1mrz\t1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa\n" {
+			t.Fatalf("%s Failed: unexpected result [%d] [%s]", t.Name(), code, stdout)
+		}
+		if code, _, _ = runCommand("", "resolve", endpoint, "-file", file+".missing"); code != exitError {
+			t.Fatalf("%s Failed: expected [%d] received: [%d]", t.Name(), exitError, code)
+		}
+	})
+
+	t.Run("invalid usage", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"resolve", "-unknown-flag"},
+			{"resolve", "-output", "xml", "1mrz"},
+			{"resolve", "-env", "staging", "1mrz"},
+			{"resolve", endpoint},
+		} {
+			if code, _, _ := runCommand("", args...); code != exitUsage {
+				t.Errorf("%s Failed: %v inputted and [%d] expected, received: [%d]", t.Name(), args, exitUsage, code)
+			}
+		}
+		if code, _, stderr := runCommand("", "resolve", "-h"); code != exitOK || !strings.Contains(stderr, "-api-endpoint") {
+			t.Fatalf("%s Failed: unexpected help [%d] [%s]", t.Name(), code, stderr)
+		}
+	})
+}
+
+// TestExitCode will test the exitCode() method
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		err      error
+		expected int
+	}{
+		{nil, exitOK},
+		{fmt.Errorf("other error"), exitError},
+		{polynym.ErrInvalidInput, exitInvalidInput},
+		{polynym.ErrNotFound, exitNotFound},
+		{polynym.ErrRateLimited, exitRateLimited},
+		{polynym.ErrUpstreamUnavailable, exitUpstream},
+		{polynym.ErrDecodeFailure, exitDecodeFailure},
+		{polynym.ErrTransportFailure, exitTransportFailure},
+		{fmt.Errorf("%w: %v", polynym.ErrTransportFailure, context.DeadlineExceeded), exitTransportFailure},
+		{context.DeadlineExceeded, exitTimeout},
+		{context.Canceled, exitTimeout},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if code := exitCode(test.err); code != test.expected {
+			t.Errorf("%s Failed: [%v] inputted and [%d] expected, received: [%d]", t.Name(), test.err, test.expected, code)
+		}
+	}
+}
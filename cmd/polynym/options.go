@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/mrz1836/go-polynym"
+)
+
+// logPrivacyKeyEnv is the environment variable for the -log-privacy-key (keeps the key out of the process list)
+const logPrivacyKeyEnv = "POLYNYM_LOG_PRIVACY_KEY"
+
+// logPrivacyKeyMinLength is the min length of the -log-privacy-key (in bytes)
+const logPrivacyKeyMinLength = 16
+
+// environments are the presets for the -env flag
+var environments = map[string]polynym.Environment{
+	polynym.EnvironmentBeta.Name:       polynym.EnvironmentBeta,
+	polynym.EnvironmentLocal.Name:      polynym.EnvironmentLocal,
+	polynym.EnvironmentProduction.Name: polynym.EnvironmentProduction,
+}
+
+// clientFlags are the flags for every field of polynym.Options (except custom HandleProviders, Observer, Tracer or Logger)
+type clientFlags struct {
+	cacheDir      string
+	dnsServer     string
+	environment   string
+	flags         *flag.FlagSet
+	logLevel      string
+	logPrivacyKey string
+	options       *polynym.Options
+}
+
+// newClientFlags will register the client flags (defaults are polynym.ClientDefaultOptions())
+func newClientFlags(fs *flag.FlagSet) *clientFlags {
+	c := &clientFlags{flags: fs, options: polynym.ClientDefaultOptions()}
+	o := c.options
+
+	fs.StringVar(&c.environment, "env", "", "environment preset: production, beta or local (sets -api-endpoint and -handcash-beta)")
+	fs.StringVar(&o.APIEndpoint, "api-endpoint", o.APIEndpoint, "base URL of the Polynym API")
+	fs.Float64Var(&o.BackOffExponentFactor, "back-off-exponent-factor", o.BackOffExponentFactor, "exponent factor of the back-off between retries")
+	fs.DurationVar(&o.BackOffInitialTimeout, "back-off-initial-timeout", o.BackOffInitialTimeout, "initial back-off between retries")
+	fs.DurationVar(&o.BackOffMaximumJitterInterval, "back-off-maximum-jitter-interval", o.BackOffMaximumJitterInterval, "max jitter added to the back-off")
+	fs.DurationVar(&o.BackOffMaxTimeout, "back-off-max-timeout", o.BackOffMaxTimeout, "max back-off between retries")
+	fs.StringVar(&c.cacheDir, "cache-dir", "", "directory for a file-backed cache (in-memory if empty, requires -cache-ttl)")
+	fs.IntVar(&o.CacheMaxEntries, "cache-max-entries", o.CacheMaxEntries, "max entries in the in-memory cache")
+	fs.DurationVar(&o.CacheNegativeTTL, "cache-negative-ttl", o.CacheNegativeTTL, "how long to cache not found results (0 is disabled)")
+	fs.DurationVar(&o.CacheTTL, "cache-ttl", o.CacheTTL, "how long to cache resolved addresses (0 is disabled)")
+	fs.DurationVar(&o.DialerKeepAlive, "dialer-keep-alive", o.DialerKeepAlive, "keep-alive of the network connections")
+	fs.DurationVar(&o.DialerTimeout, "dialer-timeout", o.DialerTimeout, "timeout for opening a network connection")
+	fs.StringVar(&c.dnsServer, "dns-server", "", "DNS server (host:port) for the paymail SRV lookups (system resolver if empty)")
+	fs.BoolVar(&o.HandCashBeta, "handcash-beta", o.HandCashBeta, "convert $handles to the beta HandCash paymails")
+	fs.StringVar(&c.logLevel, "log-level", "", "write JSON logs to stderr at or above the level: debug, info, warn or error (disabled if empty)")
+	fs.StringVar(&c.logPrivacyKey, "log-privacy-key", "", "hex key of the identifier hashes in the logs, at least 16 bytes (or "+logPrivacyKeyEnv+", random per run if empty)")
+	fs.BoolVar(&o.LogPrivacyMode, "log-privacy-mode", o.LogPrivacyMode, "hash the handles, paymails and addresses in the logs (redacted otherwise)")
+	fs.BoolVar(&o.PaymailFallback, "paymail-fallback", o.PaymailFallback, "resolve paymails natively when Polynym is unavailable (requires -paymail-sender-handle)")
+	fs.StringVar(&o.PaymailSenderHandle, "paymail-sender-handle", o.PaymailSenderHandle, "sender handle for the paymail address resolution (required by the specification)")
+	fs.BoolVar(&o.PaymailTrustSRVTarget, "paymail-trust-srv-target", o.PaymailTrustSRVTarget, "accept SRV targets outside of the paymail domain (only with a DNSSEC validating resolver)")
+	fs.IntVar(&o.RequestRetryCount, "request-retry-count", o.RequestRetryCount, "number of retries after the first attempt")
+	fs.DurationVar(&o.RequestTimeout, "request-timeout", o.RequestTimeout, "timeout of each request")
+	fs.DurationVar(&o.TransportExpectContinueTimeout, "transport-expect-continue-timeout", o.TransportExpectContinueTimeout, "expect continue timeout of the transport")
+	fs.DurationVar(&o.TransportIdleTimeout, "transport-idle-timeout", o.TransportIdleTimeout, "idle timeout of the transport connections")
+	fs.IntVar(&o.TransportMaxIdleConnections, "transport-max-idle-connections", o.TransportMaxIdleConnections, "max idle connections of the transport")
+	fs.DurationVar(&o.TransportTLSHandshakeTimeout, "transport-tls-handshake-timeout", o.TransportTLSHandshakeTimeout, "TLS handshake timeout of the transport")
+	fs.StringVar(&o.UserAgent, "user-agent", o.UserAgent, "user agent for all requests")
+
+	return c
+}
+
+// newClient will create the client from the parsed flags
+func (c *clientFlags) newClient() (*polynym.Client, error) {
+	o := c.options
+
+	// The paymail fallback requires a sender handle (the address resolution would be rejected)
+	if o.PaymailFallback && len(o.PaymailSenderHandle) == 0 {
+		return nil, fmt.Errorf("-paymail-fallback requires -paymail-sender-handle")
+	}
+
+	// Apply the environment (flags that were set explicitly take precedence)
+	if len(c.environment) > 0 {
+		environment, ok := environments[strings.ToLower(c.environment)]
+		if !ok {
+			return nil, fmt.Errorf("unknown environment: %s", c.environment)
+		}
+		set := make(map[string]bool)
+		c.flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if !set["api-endpoint"] {
+			o.APIEndpoint = environment.APIEndpoint
+		}
+		if !set["handcash-beta"] {
+			o.HandCashBeta = environment.HandCashBeta
+		}
+	}
+
+	// Write the logs to the output of the flags (stderr)
+	if len(c.logLevel) > 0 {
+		level, err := polynym.ParseLogLevel(c.logLevel)
+		if err != nil {
+			return nil, err
+		}
+		o.Logger = polynym.NewJSONLogger(c.flags.Output(), level)
+	}
+
+	// Use the same key for the identifier hashes across runs (the flag takes precedence over the environment)
+	key := c.logPrivacyKey
+	if len(key) == 0 {
+		key = os.Getenv(logPrivacyKeyEnv)
+	}
+	if len(key) > 0 {
+		decoded, err := hex.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -log-privacy-key (expected hex): %w", err)
+		} else if len(decoded) < logPrivacyKeyMinLength {
+			return nil, fmt.Errorf("invalid -log-privacy-key: expected at least %d bytes, received %d", logPrivacyKeyMinLength, len(decoded))
+		}
+		o.LogPrivacyKey = decoded
+	}
+
+	// Use a file-backed cache
+	if len(c.cacheDir) > 0 {
+		cache, err := polynym.NewFileCache(c.cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		o.Cache = cache
+	}
+
+	// Use a specific DNS server for the SRV lookups
+	if len(c.dnsServer) > 0 {
+		server := c.dnsServer
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		o.DNSResolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	return polynym.NewClient(o), nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/mrz1836/go-polynym"
+)
+
+// writeFile will write the contents to the file
+func writeFile(file, contents string) error {
+	return os.WriteFile(file, []byte(contents), 0o600)
+}
+
+// parseClientFlags will parse the arguments into client flags
+func parseClientFlags(t *testing.T, args ...string) *clientFlags {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c := newClientFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	}
+	return c
+}
+
+// TestClientFlags will test the newClientFlags() and newClient() methods
+func TestClientFlags(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		c := parseClientFlags(t)
+		if !reflect.DeepEqual(c.options, polynym.ClientDefaultOptions()) {
+			t.Fatalf("%s Failed: expected the default options, received: [%v]", t.Name(), c.options)
+		} else if client, err := c.newClient(); err != nil || client == nil {
+			t.Fatalf("%s Failed: expected a client, received error [%v]", t.Name(), err)
+		}
+	})
+
+	t.Run("all options", func(t *testing.T) {
+		c := parseClientFlags(t,
+			"-api-endpoint", "http://localhost:8080",
+			"-back-off-exponent-factor", "3",
+			"-back-off-initial-timeout", "1s",
+			"-back-off-maximum-jitter-interval", "2s",
+			"-back-off-max-timeout", "3s",
+			"-cache-max-entries", "10",
+			"-cache-negative-ttl", "1m",
+			"-cache-ttl", "1h",
+			"-dialer-keep-alive", "4s",
+			"-dialer-timeout", "5s",
+			"-handcash-beta",
+			"-log-privacy-mode",
+			"-paymail-fallback",
+			"-paymail-sender-handle", "ops@example.com",
+			"-paymail-trust-srv-target",
+			"-request-retry-count", "5",
+			"-request-timeout", "6s",
+			"-transport-expect-continue-timeout", "7s",
+			"-transport-idle-timeout", "8s",
+			"-transport-max-idle-connections", "9",
+			"-transport-tls-handshake-timeout", "10s",
+			"-user-agent", "ops",
+		)
+		o := c.options
+		if o.APIEndpoint != "http://localhost:8080" || o.BackOffExponentFactor != 3 || o.BackOffInitialTimeout.Seconds() != 1 ||
+			o.BackOffMaximumJitterInterval.Seconds() != 2 || o.BackOffMaxTimeout.Seconds() != 3 || o.CacheMaxEntries != 10 ||
+			o.CacheNegativeTTL.Minutes() != 1 || o.CacheTTL.Hours() != 1 || o.DialerKeepAlive.Seconds() != 4 ||
+			o.DialerTimeout.Seconds() != 5 || !o.HandCashBeta || !o.LogPrivacyMode || !o.PaymailFallback || o.PaymailSenderHandle != "ops@example.com" ||
+			!o.PaymailTrustSRVTarget || o.RequestRetryCount != 5 || o.RequestTimeout.Seconds() != 6 || o.TransportExpectContinueTimeout.Seconds() != 7 ||
+			o.TransportIdleTimeout.Seconds() != 8 || o.TransportMaxIdleConnections != 9 ||
+			o.TransportTLSHandshakeTimeout.Seconds() != 10 || o.UserAgent != "ops" {
+			t.Fatalf("%s Failed: unexpected options [%v]", t.Name(), o)
+		}
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		c := parseClientFlags(t, "-env", "Beta")
+		if _, err := c.newClient(); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if c.options.APIEndpoint != polynym.EnvironmentBeta.APIEndpoint || !c.options.HandCashBeta {
+			t.Fatalf("%s Failed: expected the beta environment, received: [%v]", t.Name(), c.options)
+		}
+
+		// Flags take precedence over the environment
+		c = parseClientFlags(t, "-env", "local", "-api-endpoint", "http://localhost:8080")
+		if _, err := c.newClient(); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if c.options.APIEndpoint != "http://localhost:8080" {
+			t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "http://localhost:8080", c.options.APIEndpoint)
+		}
+	})
+
+	t.Run("cache directory and dns server", func(t *testing.T) {
+		c := parseClientFlags(t, "-cache-ttl", "1h", "-cache-dir", t.TempDir(), "-dns-server", "1.1.1.1")
+		if _, err := c.newClient(); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if _, ok := c.options.Cache.(*polynym.FileCache); !ok {
+			t.Fatalf("%s Failed: expected a file cache, received: [%T]", t.Name(), c.options.Cache)
+		} else if c.options.DNSResolver == nil {
+			t.Fatalf("%s Failed: expected a DNS resolver", t.Name())
+		}
+
+		// The cache directory cannot be created
+		file := t.TempDir() + "/file"
+		if err := writeFile(file, "not a directory"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		if _, err := parseClientFlags(t, "-cache-dir", file+"/cache").newClient(); err == nil {
+			t.Fatalf("%s Failed: expected an error", t.Name())
+		}
+	})
+
+	t.Run("paymail fallback requires a sender handle", func(t *testing.T) {
+		if _, err := parseClientFlags(t, "-paymail-fallback").newClient(); err == nil {
+			t.Fatalf("%s Failed: expected an error", t.Name())
+		} else if _, err = parseClientFlags(t, "-paymail-fallback", "-paymail-sender-handle", "ops@example.com").newClient(); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		c := parseClientFlags(t, "-log-level", "WARN")
+		if _, err := c.newClient(); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if _, ok := c.options.Logger.(*polynym.JSONLogger); !ok {
+			t.Fatalf("%s Failed: expected a JSON logger, received: [%T]", t.Name(), c.options.Logger)
+		}
+		if _, err := parseClientFlags(t, "-log-level", "verbose").newClient(); err == nil {
+			t.Fatalf("%s Failed: expected an error", t.Name())
+		}
+	})
+
+	t.Run("log privacy key", func(t *testing.T) {
+		key := "000102030405060708090a0b0c0d0e0f"
+		c := parseClientFlags(t, "-log-privacy-mode", "-log-privacy-key", key)
+		if _, err := c.newClient(); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if hex.EncodeToString(c.options.LogPrivacyKey) != key {
+			t.Fatalf("%s Failed: expected [%s] received: [%x]", t.Name(), key, c.options.LogPrivacyKey)
+		}
+
+		// Invalid keys
+		for _, invalid := range []string{"not-hex", "0001020304"} {
+			if _, err := parseClientFlags(t, "-log-privacy-key", invalid).newClient(); err == nil {
+				t.Errorf("%s Failed: [%s] inputted, expected an error", t.Name(), invalid)
+			}
+		}
+	})
+
+	t.Run("log privacy key from the environment", func(t *testing.T) {
+		envKey := "0f0e0d0c0b0a09080706050403020100"
+		if err := os.Setenv(logPrivacyKeyEnv, envKey); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		defer func() {
+			_ = os.Unsetenv(logPrivacyKeyEnv)
+		}()
+
+		c := parseClientFlags(t)
+		if _, err := c.newClient(); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if hex.EncodeToString(c.options.LogPrivacyKey) != envKey {
+			t.Fatalf("%s Failed: expected [%s] received: [%x]", t.Name(), envKey, c.options.LogPrivacyKey)
+		}
+
+		// The flag takes precedence
+		key := "000102030405060708090a0b0c0d0e0f"
+		c = parseClientFlags(t, "-log-privacy-key", key)
+		if _, err := c.newClient(); err != nil || hex.EncodeToString(c.options.LogPrivacyKey) != key {
+			t.Fatalf("%s Failed: expected [%s] received: [%x] error [%v]", t.Name(), key, c.options.LogPrivacyKey, err)
+		}
+	})
+}
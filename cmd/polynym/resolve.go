@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mrz1836/go-polynym"
+)
+
+// Output formats
+const (
+	formatCSV  = "csv"
+	formatJSON = "json"
+	formatText = "text"
+)
+
+// resolveResult is the output for one input
+type resolveResult struct {
+	Address    string `json:"address,omitempty"`
+	CacheHit   bool   `json:"cache_hit"`
+	Error      string `json:"error,omitempty"`
+	Input      string `json:"input"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Type       string `json:"type"`
+}
+
+// runResolve will resolve the inputs (arguments, file or stdin) and write the results
+func runResolve(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprint(stderr, "Usage: polynym resolve [flags] [handle, paymail or address]...\n\n"+
+			"Inputs are read from the arguments, the -file or stdin (one per line, # for comments).\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	clientOptions := newClientFlags(fs)
+	file := fs.String("file", "", "file with one input per line (- for stdin)")
+	output := fs.String("output", formatText, "output format: text, json or csv")
+	timeout := fs.Duration("timeout", 0, "overall deadline for resolving all inputs (0 is no deadline)")
+	workers := fs.Int("workers", 5, "number of concurrent lookups")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	// Check the output format
+	format := strings.ToLower(*output)
+	if format != formatText && format != formatJSON && format != formatCSV {
+		_, _ = fmt.Fprintf(stderr, "unknown output format: %s\n", *output)
+		return exitUsage
+	}
+
+	// Create the client
+	client, err := clientOptions.newClient()
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err.Error())
+		return exitUsage
+	}
+
+	// Get the inputs
+	inputs := fs.Args()
+	if len(*file) > 0 || len(inputs) == 0 {
+		var fileInputs []string
+		if fileInputs, err = readInputs(*file, stdin); err != nil {
+			_, _ = fmt.Fprintln(stderr, err.Error())
+			return exitError
+		}
+		inputs = append(inputs, fileInputs...)
+	}
+	if len(inputs) == 0 {
+		_, _ = fmt.Fprintln(stderr, "missing handle, paymail or address to resolve")
+		return exitUsage
+	}
+
+	// Resolve all the inputs
+	batch := client.GetAddresses(ctx, inputs, &polynym.BatchOptions{Timeout: *timeout, Workers: *workers})
+
+	// The exit code is the class of the first failure
+	code := exitOK
+	results := make([]*resolveResult, 0, len(batch))
+	for _, item := range batch {
+		results = append(results, newResolveResult(item))
+		if code == exitOK && item.Error != nil {
+			code = exitCode(item.Error)
+		}
+	}
+
+	if err = writeResults(format, results, stdout, stderr); err != nil {
+		_, _ = fmt.Fprintln(stderr, err.Error())
+		return exitError
+	}
+	return code
+}
+
+// newResolveResult will create the output for a batch result
+func newResolveResult(item *polynym.BatchResult) *resolveResult {
+	idType, _, _ := polynym.Classify(item.Input)
+	result := &resolveResult{Input: item.Input, Type: idType.String()}
+	if item.Response != nil {
+		result.Address = item.Response.Address
+		result.CacheHit = item.Response.CacheHit
+		if item.Response.LastRequest != nil {
+			result.StatusCode = item.Response.LastRequest.StatusCode
+		}
+	}
+	if item.Error != nil {
+		result.Address = ""
+		result.Error = item.Error.Error()
+	}
+	return result
+}
+
+// readInputs will read one input per line from the file (or stdin), skipping empty lines and comments
+func readInputs(file string, stdin io.Reader) ([]string, error) {
+	reader := stdin
+	if len(file) > 0 && file != "-" {
+		f, err := os.Open(file) // nolint: gosec // the file is provided by the user
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		reader = f
+	}
+
+	var inputs []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > 0 && !strings.HasPrefix(line, "#") {
+			inputs = append(inputs, line)
+		}
+	}
+	return inputs, scanner.Err()
+}
+
+// writeResults will write the results in the output format
+//
+// The text format writes "input<tab>address" to stdout and the failures to stderr
+func writeResults(format string, results []*resolveResult, stdout, stderr io.Writer) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+
+	case formatCSV:
+		writer := csv.NewWriter(stdout)
+		_ = writer.Write([]string{"input", "type", "address", "error", "status_code", "cache_hit"})
+		for _, result := range results {
+			_ = writer.Write([]string{
+				result.Input, result.Type, result.Address, result.Error,
+				strconv.Itoa(result.StatusCode), strconv.FormatBool(result.CacheHit),
+			})
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	for _, result := range results {
+		var err error
+		if len(result.Error) > 0 {
+			_, err = fmt.Fprintf(stderr, "%s: %s\n", result.Input, result.Error)
+		} else {
+			_, err = fmt.Fprintf(stdout, "%s\t%s\n", result.Input, result.Address)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/mrz1836/go-polynym"
+)
+
+// runServe will serve the Polynym API (GET /getAddress/{id}) until the context is done
+func runServe(ctx context.Context, args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprint(stderr, "Usage: polynym serve [flags]\n\n"+
+			"Serves the Polynym API (GET /getAddress/{id}) using the client options.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	clientOptions := newClientFlags(fs)
+	addr := fs.String("addr", ":3000", "address to listen on")
+	metrics := fs.Bool("metrics", false, "serve the Prometheus metrics of the client at /metrics")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 10*time.Second, "how long to wait for requests to finish when stopping")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	} else if fs.NArg() > 0 {
+		_, _ = fmt.Fprintf(stderr, "unexpected arguments: %v\n", fs.Args())
+		return exitUsage
+	}
+
+	// Collect the metrics of the client (optional)
+	var observer *polynym.PrometheusObserver
+	if *metrics {
+		observer = polynym.NewPrometheusObserver()
+		clientOptions.options.Observer = observer
+	}
+
+	// Create the client
+	client, err := clientOptions.newClient()
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err.Error())
+		return exitUsage
+	}
+
+	// Start listening
+	var listener net.Listener
+	if listener, err = net.Listen("tcp", *addr); err != nil {
+		_, _ = fmt.Fprintln(stderr, err.Error())
+		return exitError
+	}
+	return serve(ctx, listener, newServeHandler(client, observer), *shutdownTimeout, stderr)
+}
+
+// newServeHandler returns the Polynym API handler (and the metrics at /metrics if the observer is set)
+func newServeHandler(client *polynym.Client, observer *polynym.PrometheusObserver) http.Handler {
+	handler := polynym.NewHandler(client)
+	if observer == nil {
+		return handler
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
+	mux.Handle("/metrics", observer)
+	return mux
+}
+
+// serve will serve the handler on the listener until the context is done (then shuts down gracefully)
+func serve(ctx context.Context, listener net.Listener, handler http.Handler, shutdownTimeout time.Duration, stderr io.Writer) int {
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(listener)
+	}()
+	_, _ = fmt.Fprintf(stderr, "listening on %s\n", listener.Addr().String())
+
+	select {
+	case err := <-errs:
+		_, _ = fmt.Fprintln(stderr, err.Error())
+		return exitError
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		_, _ = fmt.Fprintln(stderr, err.Error())
+		return exitError
+	}
+	return exitOK
+}
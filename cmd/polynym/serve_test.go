@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrz1836/go-polynym"
+)
+
+// TestServe will test the serve() method
+func TestServe(t *testing.T) {
+	t.Parallel()
+
+	// Use the mock Polynym as the upstream
+	upstream := newMockPolynym(t)
+	c := parseClientFlags(t, "-api-endpoint", upstream.URL)
+	client, err := c.newClient()
+	if err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	}
+
+	var listener net.Listener
+	if listener, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan int, 1)
+	var stderr bytes.Buffer
+	go func() {
+		done <- serve(ctx, listener, polynym.NewHandler(client), time.Second, &stderr)
+	}()
+
+	// Resolve using the server
+	var resp *http.Response
+	if resp, err = http.Get("http://" + listener.Addr().String() + "/getAddress/1mrz"); err != nil { // nolint: noctx // used in testing
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	}
+	response := new(polynym.GetAddressResponse)
+	err = json.NewDecoder(resp.Body).Decode(response)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	} else if resp.StatusCode != http.StatusOK || response.Address != "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa" {
+		t.Fatalf("%s Failed: unexpected response [%d] [%v]", t.Name(), resp.StatusCode, response)
+	}
+
+	// Stop the server
+	cancel()
+	if code := <-done; code != exitOK {
+		t.Fatalf("%s Failed: expected [%d] received: [%d]", t.Name(), exitOK, code)
+	} else if !strings.Contains(stderr.String(), "listening on "+listener.Addr().String()) {
+		t.Fatalf("%s Failed: unexpected output [%s]", t.Name(), stderr.String())
+	}
+}
+
+// TestRunServe will test the serve command
+func TestRunServe(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid usage", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"serve", "-unknown-flag"},
+			{"serve", "extra"},
+			{"serve", "-env", "staging"},
+		} {
+			if code, _, _ := runCommand("", args...); code != exitUsage {
+				t.Errorf("%s Failed: %v inputted and [%d] expected, received: [%d]", t.Name(), args, exitUsage, code)
+			}
+		}
+		if code, _, stderr := runCommand("", "serve", "-h"); code != exitOK || !strings.Contains(stderr, "-addr") {
+			t.Fatalf("%s Failed: unexpected help [%d] [%s]", t.Name(), code, stderr)
+		}
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		if code, _, _ := runCommand("", "serve", "-addr", "not-an-address"); code != exitError {
+			t.Fatalf("%s Failed: expected [%d] received: [%d]", t.Name(), exitError, code)
+		}
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		var stdout, stderr bytes.Buffer
+		if code := run(ctx, []string{"serve", "-addr", "127.0.0.1:0"}, nil, &stdout, &stderr); code != exitOK {
+			t.Fatalf("%s Failed: expected [%d] received: [%d] [%s]", t.Name(), exitOK, code, stderr.String())
+		}
+	})
+}
+
+// TestNewServeHandler will test the metrics of the serve handler
+func TestNewServeHandler(t *testing.T) {
+	t.Parallel()
+
+	upstream := newMockPolynym(t)
+	c := parseClientFlags(t, "-api-endpoint", upstream.URL)
+	observer := polynym.NewPrometheusObserver()
+	c.options.Observer = observer
+	client, err := c.newClient()
+	if err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	}
+
+	// Resolve and then read the metrics
+	handler := newServeHandler(client, observer)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/getAddress/1mrz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("%s Failed: expected [%d] received: [%d]", t.Name(), http.StatusOK, recorder.Code)
+	}
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(recorder.Body.String(), `polynym_resolutions_total{type="relayx",outcome="success"} 1`) {
+		t.Fatalf("%s Failed: unexpected metrics [%s]", t.Name(), recorder.Body.String())
+	}
+
+	// The metrics are not served without an observer
+	recorder = httptest.NewRecorder()
+	newServeHandler(client, nil).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("%s Failed: expected [%d] received: [%d]", t.Name(), http.StatusNotFound, recorder.Code)
+	}
+}
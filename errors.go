@@ -0,0 +1,110 @@
+package polynym
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors for resolution failures, use errors.Is() to check the kind of failure
+var (
+	// ErrNotFound is when the handle, paymail or address could not be resolved
+	ErrNotFound = errors.New("handle or paymail not found")
+
+	// ErrInvalidInput is when the handle, paymail or address is missing or malformed
+	ErrInvalidInput = errors.New("invalid handle or paymail")
+
+	// ErrUpstreamUnavailable is when polynym (or the paymail provider) returned an unexpected or server error status
+	ErrUpstreamUnavailable = errors.New("upstream is unavailable")
+
+	// ErrRateLimited is when polynym (or the paymail provider) is limiting the requests (429)
+	ErrRateLimited = errors.New("rate limited by upstream")
+
+	// ErrDecodeFailure is when the response could not be decoded
+	ErrDecodeFailure = errors.New("failed to decode response")
+
+	// ErrTransportFailure is when the request could not be completed (network, canceled context, etc)
+	ErrTransportFailure = errors.New("failed to complete request")
+
+	// ErrCapabilityNotFound is when the paymail provider does not support the capability
+	ErrCapabilityNotFound = errors.New("paymail capability not supported")
+
+	// ErrTransactionRejected is when the paymail provider rejected the submitted transaction
+	ErrTransactionRejected = errors.New("transaction rejected by receiver")
+)
+
+// Address and public key validation errors (wrapped in an ErrInvalidInput or ErrDecodeFailure error)
+var (
+	// ErrInvalidBase58 is when the address contains characters outside the Base58 alphabet
+	ErrInvalidBase58 = errors.New("invalid base58 encoding")
+
+	// ErrInvalidAddressLength is when the decoded address is not 25 bytes
+	ErrInvalidAddressLength = errors.New("invalid address length")
+
+	// ErrInvalidChecksum is when the address checksum does not match
+	ErrInvalidChecksum = errors.New("invalid address checksum")
+
+	// ErrInvalidAddressVersion is when the version byte is not a known P2PKH or P2SH version
+	ErrInvalidAddressVersion = errors.New("invalid address version")
+
+	// ErrInvalidPublicKey is when the public key is not a compressed secp256k1 public key
+	ErrInvalidPublicKey = errors.New("invalid public key")
+)
+
+// ResolveError is returned when a resolution fails, use errors.As() to access the details
+//
+// errors.Is() matches the Kind (ErrNotFound, ErrRateLimited, etc) and the underlying Err (context.Canceled, etc)
+type ResolveError struct {
+	Err         error        // Err is the underlying error (if any)
+	Kind        error        // Kind is one of the sentinel errors (ErrNotFound, ErrInvalidInput, etc)
+	LastRequest *LastRequest // LastRequest is the request that failed
+	Message     string       // Message is the raw message from upstream (if any)
+	StatusCode  int          // StatusCode is the HTTP status code (if any)
+}
+
+// newResolveError will create a new resolve error from the last request
+func newResolveError(kind error, lastRequest *LastRequest, message string, err error) *ResolveError {
+	resolveErr := &ResolveError{
+		Err:         err,
+		Kind:        kind,
+		LastRequest: lastRequest,
+		Message:     message,
+	}
+	if lastRequest != nil {
+		resolveErr.StatusCode = lastRequest.StatusCode
+	}
+	return resolveErr
+}
+
+// withLastRequest will attach the last request (and status) to a resolve error
+func withLastRequest(err error, lastRequest *LastRequest) error {
+	if resolveErr, ok := err.(*ResolveError); ok {
+		resolveErr.LastRequest = lastRequest
+		resolveErr.StatusCode = lastRequest.StatusCode
+	}
+	return err
+}
+
+// Error returns the error as a string
+func (e *ResolveError) Error() string {
+	msg := e.Kind.Error()
+	if e.StatusCode > 0 {
+		msg = fmt.Sprintf("%s (status %d)", msg, e.StatusCode)
+	}
+	if len(e.Message) > 0 {
+		msg += ": " + e.Message
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Is returns true if the target is the kind of error
+func (e *ResolveError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the underlying error
+func (e *ResolveError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,53 @@
+package polynym
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestResolveError tests the ResolveError methods
+func TestResolveError(t *testing.T) {
+	t.Parallel()
+
+	lastRequest := &LastRequest{Method: http.MethodGet, StatusCode: http.StatusBadRequest, URL: apiEndpoint + "/getAddress/1mrz"}
+
+	t.Run("error message", func(t *testing.T) {
+		err := newResolveError(ErrNotFound, lastRequest, "1handle not found", nil)
+		if err.Error() != "handle or paymail not found (status 400): 1handle not found" {
+			t.Fatalf("%s Failed: unexpected message [%s]", t.Name(), err.Error())
+		} else if err.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s Failed: expected [%d] received: [%d]", t.Name(), http.StatusBadRequest, err.StatusCode)
+		}
+	})
+
+	t.Run("is the kind", func(t *testing.T) {
+		err := error(newResolveError(ErrRateLimited, lastRequest, "", nil))
+		if !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("%s Failed: expected to match [%v]", t.Name(), ErrRateLimited)
+		} else if errors.Is(err, ErrNotFound) {
+			t.Fatalf("%s Failed: expected not to match [%v]", t.Name(), ErrNotFound)
+		}
+	})
+
+	t.Run("unwrap the cause", func(t *testing.T) {
+		err := error(newResolveError(ErrTransportFailure, nil, "", context.Canceled))
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s Failed: expected to match [%v]", t.Name(), context.Canceled)
+		} else if err.Error() != "failed to complete request: context canceled" {
+			t.Fatalf("%s Failed: unexpected message [%s]", t.Name(), err.Error())
+		}
+	})
+
+	t.Run("wrapped error", func(t *testing.T) {
+		err := fmt.Errorf("resolving: %w", newResolveError(ErrNotFound, lastRequest, "", nil))
+		var resolveErr *ResolveError
+		if !errors.As(err, &resolveErr) {
+			t.Fatalf("%s Failed: expected a ResolveError", t.Name())
+		} else if resolveErr.LastRequest != lastRequest {
+			t.Fatalf("%s Failed: expected the last request", t.Name())
+		}
+	})
+}
@@ -15,7 +15,7 @@ func main() {
 	client := polynym.NewClient(nil)
 
 	// Resolve a handle or paymail
-	resp, err := polynym.GetAddress(client, "mrz@relayx.io")
+	resp, err := client.GetAddress("mrz@relayx.io")
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -0,0 +1,8 @@
+package polynym
+
+import "net/http"
+
+// SetHTTPClient will replace the http client of the client (only used by the tests in package polynym_test)
+func SetHTTPClient(client *Client, httpClient *http.Client) {
+	client.httpClient = httpClient
+}
@@ -0,0 +1,179 @@
+package polynym
+
+import (
+	"strings"
+)
+
+// HandleProvider converts the handle syntax of a wallet into a paymail
+//
+// Providers are consulted by Classify() (and every resolution method) before the input is
+// treated as a paymail or address. Add your own to a client with Options.HandleProviders
+type HandleProvider interface {
+	Convert(handle string, isBeta bool) (paymail string, err error) // Validates and converts the handle (1mrz -> mrz@relayx.io)
+	Match(handle string) bool                                       // Returns true if the input uses the handle syntax of the provider
+	Type() IdentifierType                                           // The type of identifier (unique per provider)
+}
+
+// PrefixProvider is a HandleProvider for handles that use a prefix (^mrz -> mrz@domain.com)
+//
+// Handles are lowercased and can use a-z, 0-9, "-", "_" and "."
+type PrefixProvider struct {
+	BetaDomain string         // Paymail domain when using beta (defaults to Domain)
+	Domain     string         // Paymail domain (handcash.io)
+	Identifier IdentifierType // Type of identifier (handcash)
+	MaxLength  int            // Max length of the handle including the prefix (0 is no limit)
+	Prefix     string         // Prefix of the handle ($)
+}
+
+// Convert will validate the handle and convert it to a paymail
+func (p *PrefixProvider) Convert(handle string, isBeta bool) (string, error) {
+	alias := strings.TrimPrefix(handle, p.Prefix)
+	if !p.Match(handle) || !isValidHandle(alias) {
+		return "", newResolveError(ErrInvalidInput, nil, "invalid "+p.Prefix+"handle: "+handle, nil)
+	}
+	domain := p.Domain
+	if isBeta && len(p.BetaDomain) > 0 {
+		domain = p.BetaDomain
+	}
+	return strings.ToLower(alias) + "@" + domain, nil
+}
+
+// Match returns true if the handle starts with the prefix (and is not too long)
+func (p *PrefixProvider) Match(handle string) bool {
+	return len(p.Prefix) > 0 && strings.HasPrefix(handle, p.Prefix) &&
+		(p.MaxLength <= 0 || len(handle) <= p.MaxLength)
+}
+
+// Type returns the type of identifier
+func (p *PrefixProvider) Type() IdentifierType {
+	return p.Identifier
+}
+
+// handCashProvider is the built-in provider for HandCash $handles
+type handCashProvider struct{}
+
+// Convert will validate the $handle and convert it using HandCashConvert()
+func (handCashProvider) Convert(handle string, isBeta bool) (string, error) {
+	if !strings.HasPrefix(handle, "$") || !isValidHandle(handle[1:]) {
+		return "", newResolveError(ErrInvalidInput, nil, "invalid $handle: "+handle, nil)
+	}
+	return HandCashConvert(handle, isBeta), nil
+}
+
+// Match returns true if the handle starts with $
+func (handCashProvider) Match(handle string) bool {
+	return strings.HasPrefix(handle, "$")
+}
+
+// Type returns IdentifierHandCash
+func (handCashProvider) Type() IdentifierType {
+	return IdentifierHandCash
+}
+
+// relayXProvider is the built-in provider for RelayX 1handles
+type relayXProvider struct{}
+
+// Convert will validate the 1handle and convert it using RelayXConvert()
+func (relayXProvider) Convert(handle string, _ bool) (string, error) {
+	return RelayXConvert(handle)
+}
+
+// Match returns true if the handle starts with 1 and is short enough to not be an address
+func (relayXProvider) Match(handle string) bool {
+	return strings.HasPrefix(handle, "1") && len(handle) <= relayXMaxLength
+}
+
+// Type returns IdentifierRelayX
+func (relayXProvider) Type() IdentifierType {
+	return IdentifierRelayX
+}
+
+// twetchProvider is the built-in provider for Twetch user ids
+type twetchProvider struct{}
+
+// Convert will validate the user id and convert it using TwetchConvert()
+func (twetchProvider) Convert(userID string, _ bool) (string, error) {
+	return TwetchConvert(userID)
+}
+
+// Match returns true if the user id starts with @
+func (twetchProvider) Match(userID string) bool {
+	return strings.HasPrefix(userID, "@")
+}
+
+// Type returns IdentifierTwetch
+func (twetchProvider) Type() IdentifierType {
+	return IdentifierTwetch
+}
+
+// handleRegistry is the list of providers (checked in order), it is not changed once created
+type handleRegistry struct {
+	providers []HandleProvider
+}
+
+// builtinHandles is the registry used by Classify() and clients without custom providers
+var builtinHandles = newHandleRegistry()
+
+// newHandleRegistry will create a registry with the built-in providers (HandCash, RelayX and Twetch) and the custom providers
+//
+// A provider with the same Type() replaces the existing one (including the built-ins),
+// otherwise the provider is checked before the built-ins (custom providers are checked in order)
+func newHandleRegistry(providers ...HandleProvider) *handleRegistry {
+	r := &handleRegistry{providers: []HandleProvider{handCashProvider{}, relayXProvider{}, twetchProvider{}}}
+	for i := len(providers) - 1; i >= 0; i-- {
+		r.register(providers[i])
+	}
+	return r
+}
+
+// HandleProviders returns the handle providers of the client (in the order they are checked)
+func (c *Client) HandleProviders() []HandleProvider {
+	return c.handleRegistry().list()
+}
+
+// handleRegistry returns the registry of the client (the built-in providers if not set)
+func (c *Client) handleRegistry() *handleRegistry {
+	if c.handles == nil {
+		return builtinHandles
+	}
+	return c.handles
+}
+
+// register will add or replace the provider
+func (r *handleRegistry) register(provider HandleProvider) {
+	if provider == nil {
+		return
+	}
+	for i, existing := range r.providers {
+		if existing.Type() == provider.Type() {
+			r.providers[i] = provider
+			return
+		}
+	}
+	r.providers = append([]HandleProvider{provider}, r.providers...)
+}
+
+// list returns a copy of the providers
+func (r *handleRegistry) list() []HandleProvider {
+	return append([]HandleProvider(nil), r.providers...)
+}
+
+// match returns the first provider that matches the handle (or nil)
+func (r *handleRegistry) match(handle string) HandleProvider {
+	for _, provider := range r.providers {
+		if provider.Match(handle) {
+			return provider
+		}
+	}
+	return nil
+}
+
+// isHandleType returns true if the identifier type belongs to a provider
+func (r *handleRegistry) isHandleType(idType IdentifierType) bool {
+	for _, provider := range r.providers {
+		if provider.Type() == idType {
+			return true
+		}
+	}
+	return false
+}
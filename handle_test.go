@@ -0,0 +1,175 @@
+package polynym
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// mockHandleProvider is a provider that always fails to convert
+type mockHandleProvider struct{}
+
+// Convert returns an error that is not ErrInvalidInput
+func (mockHandleProvider) Convert(_ string, _ bool) (string, error) {
+	return "", fmt.Errorf("provider is broken")
+}
+
+// Match returns true for handles starting with !
+func (mockHandleProvider) Match(handle string) bool {
+	return len(handle) > 0 && handle[0] == '!'
+}
+
+// Type returns the mock type
+func (mockHandleProvider) Type() IdentifierType {
+	return IdentifierType("mock")
+}
+
+// TestPrefixProvider will test the PrefixProvider methods
+func TestPrefixProvider(t *testing.T) {
+	t.Parallel()
+
+	provider := &PrefixProvider{
+		BetaDomain: "beta.wallet.com",
+		Domain:     "wallet.com",
+		Identifier: IdentifierType("wallet"),
+		MaxLength:  10,
+		Prefix:     "^",
+	}
+
+	// Create the list of tests
+	var tests = []struct {
+		input         string
+		beta          bool
+		expected      string
+		expectedError bool
+	}{
+		{"^MrZ", false, "mrz@wallet.com", false},
+		{"^mr-z", true, "mr-z@beta.wallet.com", false},
+		{"^", false, "", true},
+		{"^mr z", false, "", true},
+		{"^123456789", false, "123456789@wallet.com", false},
+		{"^1234567890", false, "", true},
+		{"mrz", false, "", true},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if output, err := provider.Convert(test.input, test.beta); err == nil && test.expectedError {
+			t.Errorf("%s Failed: expected to throw an error, no error [%s] inputted", t.Name(), test.input)
+		} else if err != nil && !test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if err != nil && !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s Failed: [%s] inputted, expected [%v] received: [%v]", t.Name(), test.input, ErrInvalidInput, err)
+		} else if output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expected, output)
+		}
+	}
+
+	// No beta domain uses the domain
+	provider.BetaDomain = ""
+	if output, _ := provider.Convert("^mrz", true); output != "mrz@wallet.com" {
+		t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "mrz@wallet.com", output)
+	} else if provider.Type() != "wallet" {
+		t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "wallet", provider.Type())
+	}
+}
+
+// TestClient_HandleProviders will test the custom handle providers of the client (Options.HandleProviders)
+func TestClient_HandleProviders(t *testing.T) {
+	t.Parallel()
+
+	t.Run("built-in providers", func(t *testing.T) {
+		providers := NewClient(nil).HandleProviders()
+		if len(providers) != 3 {
+			t.Fatalf("%s Failed: expected the built-in providers, received: [%d]", t.Name(), len(providers))
+		}
+		if !builtinHandles.isHandleType(IdentifierHandCash) || !builtinHandles.isHandleType(IdentifierRelayX) ||
+			!builtinHandles.isHandleType(IdentifierTwetch) {
+			t.Fatalf("%s Failed: missing a built-in provider", t.Name())
+		} else if builtinHandles.isHandleType(IdentifierPaymail) {
+			t.Fatalf("%s Failed: paymail is not a handle", t.Name())
+		}
+	})
+
+	t.Run("custom provider", func(t *testing.T) {
+		options := ClientDefaultOptions()
+		options.HandleProviders = []HandleProvider{&PrefixProvider{
+			Domain:     "tilde.com",
+			Identifier: IdentifierType("tilde"),
+			Prefix:     "~",
+		}, nil}
+		client := NewClient(options)
+
+		idType, paymail, err := client.Classify("~MrZ")
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if idType != "tilde" || paymail != "mrz@tilde.com" {
+			t.Fatalf("%s Failed: expected [%s] received: [%s] [%s]", t.Name(), "mrz@tilde.com", idType, paymail)
+		}
+
+		// The paymail methods accept the handle
+		if paymail, err = client.paymailFromInput("~mrz"); err != nil || paymail != "mrz@tilde.com" {
+			t.Fatalf("%s Failed: expected [%s] received: [%s] [%v]", t.Name(), "mrz@tilde.com", paymail, err)
+		}
+
+		// Other clients (and Classify) are not changed
+		if _, _, err = Classify("~mrz"); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrInvalidInput, err)
+		} else if _, _, err = NewClient(nil).Classify("~mrz"); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrInvalidInput, err)
+		}
+	})
+
+	t.Run("provider errors are invalid input", func(t *testing.T) {
+		client := &Client{handles: newHandleRegistry(mockHandleProvider{})}
+		if idType, _, err := client.Classify("!mrz"); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrInvalidInput, err)
+		} else if idType != "mock" {
+			t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "mock", idType)
+		}
+	})
+
+	t.Run("replace and order", func(t *testing.T) {
+		custom := &PrefixProvider{Domain: "cash.com", Identifier: IdentifierHandCash, Prefix: "$"}
+		registry := newHandleRegistry(
+			&PrefixProvider{Domain: "one.com", Identifier: IdentifierType("one"), Prefix: "1"},
+			&PrefixProvider{Domain: "two.com", Identifier: IdentifierType("two"), Prefix: "1"},
+			custom,
+		)
+
+		providers := registry.list()
+		if len(providers) != 5 {
+			t.Fatalf("%s Failed: expected [%d] providers, received: [%d]", t.Name(), 5, len(providers))
+		} else if providers[2] != custom {
+			t.Fatalf("%s Failed: expected the built-in HandCash provider to be replaced", t.Name())
+		} else if provider := registry.match("1mrz"); provider == nil || provider.Type() != "one" {
+			t.Fatalf("%s Failed: expected the custom providers to be checked first (in order)", t.Name())
+		} else if registry.match("mrz@handcash.io") != nil {
+			t.Fatalf("%s Failed: expected no provider to match a paymail", t.Name())
+		} else if len(builtinHandles.list()) != 3 {
+			t.Fatalf("%s Failed: the built-in providers should not change", t.Name())
+		}
+	})
+}
+
+// ExampleClient_Classify example using custom handle providers with client.Classify()
+func ExampleClient_Classify() {
+	options := ClientDefaultOptions()
+	options.HandleProviders = []HandleProvider{&PrefixProvider{
+		Domain:     "example.com",
+		Identifier: IdentifierType("example"),
+		Prefix:     "+",
+	}}
+	client := NewClient(options)
+	idType, paymail, _ := client.Classify("+MrZ")
+	fmt.Println(idType, paymail)
+	// Output:example mrz@example.com
+}
+
+// BenchmarkPrefixProvider_Convert benchmarks the PrefixProvider.Convert method
+func BenchmarkPrefixProvider_Convert(b *testing.B) {
+	provider := &PrefixProvider{Domain: "example.com", Identifier: IdentifierType("example"), Prefix: "+"}
+	for i := 0; i < b.N; i++ {
+		_, _ = provider.Convert("+mrz", false)
+	}
+}
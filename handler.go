@@ -0,0 +1,79 @@
+package polynym
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// handlerPath is the path of the Polynym API that is served by the Handler
+const handlerPath = "/getAddress/"
+
+// Handler is an http.Handler that implements the Polynym API (GET /getAddress/{id})
+//
+// Responses use the same {"address","error"} JSON as GetAddressResponse, so a Client (or any
+// Polynym client) can use it as the API endpoint. Lookups use the resolver, so the cache,
+// native paymail fallback and handle providers of the Client are all applied.
+type Handler struct {
+	resolver Resolver
+}
+
+// NewHandler will create a new Polynym API handler using the resolver (usually a *Client)
+func NewHandler(resolver Resolver) *Handler {
+	return &Handler{resolver: resolver}
+}
+
+// ServeHTTP will resolve the handle, paymail or address in the path
+//
+// Invalid inputs and not found results are a 400 (like Polynym), rate limits are a 429,
+// timeouts are a 504 and any other failure is a 502
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !strings.HasPrefix(req.URL.Path, handlerPath) || strings.Contains(req.URL.Path[len(handlerPath):], "/") {
+		respondJSON(w, http.StatusNotFound, &GetAddressResponse{ErrorMessage: "not found"})
+		return
+	} else if req.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		respondJSON(w, http.StatusMethodNotAllowed, &GetAddressResponse{ErrorMessage: "method not allowed"})
+		return
+	}
+
+	// Resolve the identifier (the path is already unescaped: %24mrz -> $mrz)
+	response, err := h.resolver.GetAddressWithContext(req.Context(), req.URL.Path[len(handlerPath):])
+	if err == nil {
+		respondJSON(w, http.StatusOK, &GetAddressResponse{Address: response.Address})
+		return
+	}
+
+	// Use the upstream message (if any) for the error
+	message := err.Error()
+	var resolveErr *ResolveError
+	if errors.As(err, &resolveErr) {
+		message = resolveErr.Kind.Error()
+		if len(resolveErr.Message) > 0 {
+			message = resolveErr.Message
+		}
+	}
+	respondJSON(w, handlerStatus(err), &GetAddressResponse{ErrorMessage: message})
+}
+
+// handlerStatus returns the status code for the kind of error
+func handlerStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+// respondJSON will write the value as JSON with the status code
+func respondJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
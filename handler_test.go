@@ -0,0 +1,147 @@
+package polynym
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHandler will test the Handler using a Client (round trip)
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(NewHandler(newMockClient(defaultUserAgent)))
+	t.Cleanup(server.Close)
+
+	options := ClientDefaultOptions()
+	options.APIEndpoint = server.URL
+	options.RequestRetryCount = 0
+	client := NewClient(options)
+
+	// Create the list of tests
+	var tests = []struct {
+		input         string
+		expected      string
+		expectedError error
+		statusCode    int
+	}{
+		{"1mrz", "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa", nil, http.StatusOK},
+		{"$mr-z", "124dwBFyFtkcNXGfVWQroGcT9ybnpQ3G3Z", nil, http.StatusOK},
+		{"@833", "19ksW6ueSw9nEj88X3QNJ9VkKPGf1zuKbQ", nil, http.StatusOK},
+		{"doesnotexist@handcash.io", "", ErrNotFound, http.StatusBadRequest},
+		{"rate-limited@test.com", "", ErrRateLimited, http.StatusTooManyRequests},
+		{"bad-poly-json@test.com", "", ErrUpstreamUnavailable, http.StatusBadGateway},
+		{"error@test.com", "", ErrUpstreamUnavailable, http.StatusBadGateway},
+	}
+
+	// Test all
+	for _, test := range tests {
+		output, err := client.GetAddress(test.input)
+		if test.expectedError != nil && !errors.Is(err, test.expectedError) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.input, test.expectedError, err)
+		} else if test.expectedError == nil && err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if output.Address != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expected, output.Address)
+		} else if output.LastRequest.StatusCode != test.statusCode {
+			t.Errorf("%s Failed: [%s] inputted and [%d] expected, received: [%d]", t.Name(), test.input, test.statusCode, output.LastRequest.StatusCode)
+		}
+	}
+
+	// The upstream message is returned
+	var resolveErr *ResolveError
+	if _, err := client.GetAddress("doesnotexist@handcash.io"); !errors.As(err, &resolveErr) {
+		t.Fatalf("%s Failed: expected a resolve error, received: [%v]", t.Name(), err)
+	} else if resolveErr.Message != "$handle not found" {
+		t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "$handle not found", resolveErr.Message)
+	}
+}
+
+// TestHandler_ServeHTTP will test the requests to the Handler
+func TestHandler_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	handler := NewHandler(newMockClient(defaultUserAgent))
+
+	// Create the list of tests
+	var tests = []struct {
+		method          string
+		path            string
+		expectedStatus  int
+		expectedAddress string
+		expectedError   string
+	}{
+		{http.MethodGet, "/getAddress/%24mr-z", http.StatusOK, "124dwBFyFtkcNXGfVWQroGcT9ybnpQ3G3Z", ""},
+		{http.MethodGet, "/getAddress/19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", http.StatusOK, "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", ""},
+		{http.MethodGet, "/getAddress/bad%20input", http.StatusBadRequest, "", "unrecognized handle, paymail or address: bad input"},
+		{http.MethodGet, "/getAddress/", http.StatusBadRequest, "", "missing handle or paymail to resolve"},
+		{http.MethodGet, "/getAddress/1mrz/extra", http.StatusNotFound, "", "not found"},
+		{http.MethodGet, "/", http.StatusNotFound, "", "not found"},
+		{http.MethodPost, "/getAddress/1mrz", http.StatusMethodNotAllowed, "", "method not allowed"},
+	}
+
+	// Test all
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+
+		response := new(GetAddressResponse)
+		if recorder.Code != test.expectedStatus {
+			t.Errorf("%s Failed: [%s] inputted and [%d] expected, received: [%d]", t.Name(), test.path, test.expectedStatus, recorder.Code)
+		} else if recorder.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s Failed: [%s] inputted, unexpected content type [%s]", t.Name(), test.path, recorder.Header().Get("Content-Type"))
+		} else if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.path, err.Error())
+		} else if response.Address != test.expectedAddress || response.ErrorMessage != test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted, unexpected response [%v]", t.Name(), test.path, response)
+		}
+	}
+}
+
+// TestHandlerStatus will test the handlerStatus() method
+func TestHandlerStatus(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		err      error
+		expected int
+	}{
+		{ErrInvalidInput, http.StatusBadRequest},
+		{ErrNotFound, http.StatusBadRequest},
+		{ErrRateLimited, http.StatusTooManyRequests},
+		{newResolveError(ErrTransportFailure, nil, "", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{context.Canceled, http.StatusGatewayTimeout},
+		{ErrUpstreamUnavailable, http.StatusBadGateway},
+		{ErrDecodeFailure, http.StatusBadGateway},
+		{fmt.Errorf("other error"), http.StatusBadGateway},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if status := handlerStatus(test.err); status != test.expected {
+			t.Errorf("%s Failed: [%v] inputted and [%d] expected, received: [%d]", t.Name(), test.err, test.expected, status)
+		}
+	}
+}
+
+// ExampleNewHandler example using NewHandler()
+func ExampleNewHandler() {
+	handler := NewHandler(newMockClient(defaultUserAgent))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/getAddress/1mrz", nil))
+	fmt.Print(recorder.Body.String())
+	// Output:{"address":"1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa","error":""}
+}
+
+// BenchmarkHandler_ServeHTTP benchmarks the Handler.ServeHTTP method
+func BenchmarkHandler_ServeHTTP(b *testing.B) {
+	handler := NewHandler(newMockClient(defaultUserAgent))
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/getAddress/1mrz", nil))
+	}
+}
@@ -0,0 +1,173 @@
+package polynym
+
+import (
+	"errors"
+	"strings"
+)
+
+// IdentifierType is the type of handle, paymail or address
+type IdentifierType string
+
+// Supported identifier types
+const (
+	IdentifierUnknown  IdentifierType = "unknown"  // Unknown or invalid identifier
+	IdentifierRelayX   IdentifierType = "relayx"   // RelayX 1handle (1mrz)
+	IdentifierHandCash IdentifierType = "handcash" // HandCash $handle ($mrz)
+	IdentifierPaymail  IdentifierType = "paymail"  // Paymail (mrz@moneybutton.com)
+	IdentifierTwetch   IdentifierType = "twetch"   // Twetch user id (@833)
+	IdentifierAddress  IdentifierType = "address"  // BitcoinSV address (1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa)
+)
+
+const (
+
+	// base58Alphabet is the alphabet used for BitcoinSV addresses
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+	// relayXMaxLength is the max length of a 1handle (including the prefix)
+	relayXMaxLength = 24
+
+	// relayXMinLength is the min length of a 1handle (including the prefix)
+	relayXMinLength = 2
+
+	// twetchMaxLength is the max number of digits in a Twetch user id
+	twetchMaxLength = 12
+)
+
+// String returns the identifier type as a string
+func (i IdentifierType) String() string {
+	return string(i)
+}
+
+// Classify will detect the type of identifier and return the normalized version
+//
+// Handles ($handle, 1handle and @userID) are normalized to their paymail, paymails are lowercased and
+// addresses are returned as-is. Addresses are validated using Base58Check. No network requests are made.
+// Use client.Classify() for the handle providers and the HandCash network of a client.
+func Classify(input string) (IdentifierType, string, error) {
+	return classify(input, false, builtinHandles)
+}
+
+// Classify will detect the type of identifier and return the normalized version (see Classify())
+//
+// The handle providers of the client (Options.HandleProviders) and its HandCash network are used
+func (c *Client) Classify(input string) (IdentifierType, string, error) {
+	return c.classify(input)
+}
+
+// classify will detect the type of identifier using the handle providers and the HandCash network of the client
+func (c *Client) classify(input string) (IdentifierType, string, error) {
+	return classify(input, c.handCashBeta, c.handleRegistry())
+}
+
+// classify will detect the type of identifier and return the normalized version (using beta HandCash if set)
+func classify(input string, isBeta bool, handles *handleRegistry) (IdentifierType, string, error) {
+	input = strings.TrimSpace(input)
+	if len(input) == 0 {
+		return IdentifierUnknown, input, newResolveError(ErrInvalidInput, nil, "missing handle or paymail to resolve", nil)
+	}
+
+	// Handles are converted by the registered providers (HandCash, RelayX, etc)
+	if provider := handles.match(input); provider != nil {
+		paymail, err := provider.Convert(input, isBeta)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidInput) {
+				err = newResolveError(ErrInvalidInput, nil, "invalid handle: "+input, err)
+			}
+			return provider.Type(), input, err
+		}
+		return provider.Type(), paymail, nil
+	}
+
+	switch {
+	case strings.Contains(input, "@"):
+		if !isValidPaymail(input) {
+			return IdentifierPaymail, input, newResolveError(ErrInvalidInput, nil, "invalid paymail: "+input, nil)
+		}
+		return IdentifierPaymail, strings.ToLower(input), nil
+
+	case looksLikeAddress(input):
+		if _, err := DecodeAddress(input); err != nil {
+			return IdentifierAddress, input, err
+		}
+		return IdentifierAddress, input, nil
+	}
+
+	return IdentifierUnknown, input, newResolveError(ErrInvalidInput, nil, "unrecognized handle, paymail or address: "+input, nil)
+}
+
+// isValidHandle returns true if the handle (without the prefix) is not empty and only uses valid characters
+func isValidHandle(handle string) bool {
+	if len(handle) == 0 {
+		return false
+	}
+	for _, r := range handle {
+		if !isAlphaNumeric(r) && r != '-' && r != '_' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidPaymail returns true if the paymail is in the alias@domain.tld format
+func isValidPaymail(paymail string) bool {
+	parts := strings.Split(paymail, "@")
+	if len(parts) != 2 || len(parts[0]) == 0 {
+		return false
+	}
+	for _, r := range parts[0] {
+		if !isAlphaNumeric(r) && !strings.ContainsRune("-_.+", r) {
+			return false
+		}
+	}
+	return isValidDomain(parts[1])
+}
+
+// isValidDomain returns true if the domain has at least two valid labels (domain.tld)
+func isValidDomain(domain string) bool {
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !isAlphaNumeric(r) && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// looksLikeAddress returns true if the input has the length, prefix and alphabet of a BitcoinSV address
+func looksLikeAddress(input string) bool {
+	if len(input) < 26 || len(input) > 35 || !strings.ContainsAny(input[:1], "123mn") {
+		return false
+	}
+	for _, r := range input {
+		if !strings.ContainsRune(base58Alphabet, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isAlphaNumeric returns true if the rune is a-z, A-Z or 0-9
+func isAlphaNumeric(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// isDigits returns true if the string is not empty and only contains 0-9
+func isDigits(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
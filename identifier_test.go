@@ -0,0 +1,78 @@
+package polynym
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestClassify will test the Classify() method
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		input          string
+		expectedType   IdentifierType
+		expectedOutput string
+		expectedError  bool
+	}{
+		{"", IdentifierUnknown, "", true},
+		{"   ", IdentifierUnknown, "", true},
+		{"$MrZ", IdentifierHandCash, "mrz@handcash.io", false},
+		{"$mr-z", IdentifierHandCash, "mr-z@handcash.io", false},
+		{" $mrz ", IdentifierHandCash, "mrz@handcash.io", false},
+		{"$", IdentifierHandCash, "$", true},
+		{"$mr z", IdentifierHandCash, "$mr z", true},
+		{"1mrz", IdentifierRelayX, "mrz@relayx.io", false},
+		{"1MrZ", IdentifierRelayX, "mrz@relayx.io", false},
+		{"1", IdentifierRelayX, "1", true},
+		{"1mr$z", IdentifierRelayX, "1mr$z", true},
+		{"MrZ@HandCash.io", IdentifierPaymail, "mrz@handcash.io", false},
+		{"mrz@moneybutton.com", IdentifierPaymail, "mrz@moneybutton.com", false},
+		{"first.last+tag@sub.domain.com", IdentifierPaymail, "first.last+tag@sub.domain.com", false},
+		{"mrz@localhost", IdentifierPaymail, "mrz@localhost", true},
+		{"mrz@@handcash.io", IdentifierPaymail, "mrz@@handcash.io", true},
+		{"mrz@-handcash.io", IdentifierPaymail, "mrz@-handcash.io", true},
+		{"@833", IdentifierTwetch, "833@twetch.me", false},
+		{"@0833", IdentifierTwetch, "@0833", true},
+		{"@1234567890123", IdentifierTwetch, "@1234567890123", true},
+		{"@mrz", IdentifierTwetch, "@mrz", true},
+		{"@", IdentifierTwetch, "@", true},
+		{"19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", IdentifierAddress, "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", false},
+		{"3P14159f73E4gFr7JterCCQh9QjiTjiZrG", IdentifierAddress, "3P14159f73E4gFr7JterCCQh9QjiTjiZrG", false},
+		{"19gKzz8XmFDyrpk4qFobG7qKoqybe78v0h", IdentifierUnknown, "19gKzz8XmFDyrpk4qFobG7qKoqybe78v0h", true},
+		{"19gKzz8XmFDyrpk4qFobG7qKoqybe78v9i", IdentifierAddress, "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9i", true},
+		{"c6ZqP5Tb22KJuvSAbjNkoi", IdentifierUnknown, "c6ZqP5Tb22KJuvSAbjNkoi", true},
+		{"not-valid", IdentifierUnknown, "not-valid", true},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if idType, output, err := Classify(test.input); err == nil && test.expectedError {
+			t.Errorf("%s Failed: expected to throw an error, no error [%s] inputted", t.Name(), test.input)
+		} else if err != nil && !test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if err != nil && !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s Failed: [%s] inputted, expected [%v] received: [%v]", t.Name(), test.input, ErrInvalidInput, err)
+		} else if idType != test.expectedType {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expectedType, idType)
+		} else if !test.expectedError && output != test.expectedOutput {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expectedOutput, output)
+		}
+	}
+}
+
+// ExampleClassify example using Classify()
+func ExampleClassify() {
+	idType, paymail, _ := Classify("$MrZ")
+	fmt.Println(idType, paymail)
+	// Output:handcash mrz@handcash.io
+}
+
+// BenchmarkClassify benchmarks the Classify method
+func BenchmarkClassify(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _, _ = Classify("$mr-z")
+	}
+}
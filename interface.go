@@ -0,0 +1,53 @@
+package polynym
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// Resolver is the interface for resolving handles, paymails and addresses
+//
+// Client satisfies this interface, depend on it to swap in fakes for testing
+type Resolver interface {
+	GetAddress(handleOrPaymail string) (*GetAddressResponse, error)
+	GetAddresses(ctx context.Context, inputs []string, opts *BatchOptions) []*BatchResult
+	GetAddressWithContext(ctx context.Context, handleOrPaymail string) (*GetAddressResponse, error)
+}
+
+// Cache is the interface for storing resolved addresses, implement it to plug in a shared store
+//
+// Values are opaque bytes, the ttl is how long the value should be kept. Errors are treated as a miss.
+type Cache interface {
+	Delete(ctx context.Context, key string) error
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// DNSResolver is the interface for SRV lookups (net.Resolver satisfies it), inject one for testing
+type DNSResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error)
+}
+
+// Observer is the interface for metrics, set it on the Options to observe every lookup and HTTP request
+//
+// The methods are called synchronously from concurrent lookups, they must be fast and safe for concurrent use
+type Observer interface {
+	ObserveRequest(event *RequestEvent)
+	ObserveResolution(event *ResolutionEvent)
+}
+
+// Ensure the Client satisfies the Resolver interface
+var _ Resolver = (*Client)(nil)
+
+// Ensure the net.Resolver satisfies the DNSResolver interface
+var _ DNSResolver = (*net.Resolver)(nil)
+
+// Ensure the built-in caches satisfy the Cache interface
+var (
+	_ Cache = (*FileCache)(nil)
+	_ Cache = (*MemoryCache)(nil)
+)
+
+// Ensure the PrometheusObserver satisfies the Observer interface
+var _ Observer = (*PrometheusObserver)(nil)
@@ -0,0 +1,333 @@
+package polynym
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// LogLevel is the severity of a log entry
+type LogLevel int
+
+// Log levels (in order of severity)
+const (
+	LogLevelDebug LogLevel = iota // Requests, cache events and the start of a lookup
+	LogLevelInfo                  // Successful lookups
+	LogLevelWarn                  // Failed lookups, retries and cache errors
+	LogLevelError                 // Decode failures
+)
+
+// logLevelNames are the names of the log levels
+var logLevelNames = map[LogLevel]string{
+	LogLevelDebug: "debug",
+	LogLevelInfo:  "info",
+	LogLevelWarn:  "warn",
+	LogLevelError: "error",
+}
+
+// Logger is the interface for structured logging, set it on the Options to log the lookups and requests
+//
+// The client logs the start and finish of lookups and requests, retries, cache events and decode
+// failures. The fields are strings, numbers and booleans (durations are in milliseconds): identifier_type,
+// identifier (redacted, or hashed in the privacy mode), outcome, status, duration_ms, attempt, error, etc.
+// URLs are never logged, so identifiers only appear in the identifier field.
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, message string, fields map[string]interface{})
+}
+
+// LoggerFunc is an adapter to use a function as a Logger
+type LoggerFunc func(ctx context.Context, level LogLevel, message string, fields map[string]interface{})
+
+// Log calls the function
+func (f LoggerFunc) Log(ctx context.Context, level LogLevel, message string, fields map[string]interface{}) {
+	f(ctx, level, message, fields)
+}
+
+// String returns the name of the log level
+func (l LogLevel) String() string {
+	if name, ok := logLevelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLogLevel returns the log level for the name (debug, info, warn or error)
+func ParseLogLevel(name string) (LogLevel, error) {
+	for level, levelName := range logLevelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return LogLevelDebug, fmt.Errorf("unknown log level: %s", name)
+}
+
+// JSONLogger is a Logger that writes one JSON object per line: {"time","level","message",...fields}
+type JSONLogger struct {
+	minLevel LogLevel
+	mu       sync.Mutex
+	writer   io.Writer
+}
+
+// NewJSONLogger will create a new JSON logger that writes the entries at or above the level
+func NewJSONLogger(w io.Writer, minLevel LogLevel) *JSONLogger {
+	return &JSONLogger{minLevel: minLevel, writer: w}
+}
+
+// Log will write the entry (if at or above the level of the logger)
+func (j *JSONLogger) Log(_ context.Context, level LogLevel, message string, fields map[string]interface{}) {
+	if level < j.minLevel {
+		return
+	}
+	entry := make(map[string]interface{}, len(fields)+3)
+	for key, value := range fields {
+		entry[key] = value
+	}
+	entry["level"] = level.String()
+	entry["message"] = message
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, _ = j.writer.Write(append(data, '\n'))
+}
+
+// logFieldsKey is the context key of the fields of the lookup (added to the request logs)
+type logFieldsKey struct{}
+
+// log will send the entry to the logger (if set) with the fields of the lookup in the context
+func (c *Client) log(ctx context.Context, level LogLevel, message string, fields map[string]interface{}) {
+	if c.logger == nil {
+		return
+	}
+	if lookupFields, ok := ctx.Value(logFieldsKey{}).(map[string]interface{}); ok {
+		for key, value := range lookupFields {
+			if _, exists := fields[key]; !exists {
+				fields[key] = value
+			}
+		}
+	}
+	c.logger.Log(ctx, level, message, fields)
+}
+
+// withLogFields returns the context with the identifier fields of the lookup (if a logger is set)
+func (c *Client) withLogFields(ctx context.Context, idType IdentifierType, identifier string) context.Context {
+	if c.logger == nil {
+		return ctx
+	}
+	logIdentifier, err := c.logIdentifier(identifier)
+	ctx = context.WithValue(ctx, logFieldsKey{}, map[string]interface{}{
+		"identifier":      logIdentifier,
+		"identifier_type": idType.String(),
+	})
+	if err != nil {
+		c.log(ctx, LogLevelError, "log privacy key unavailable", map[string]interface{}{"error": err.Error()})
+	}
+	return ctx
+}
+
+// logIdentifier returns the identifier for the logs (hashed in the privacy mode, otherwise redacted)
+//
+// If the privacy mode has no key (LogPrivacyKey is not set and the random key failed) the identifier is omitted
+func (c *Client) logIdentifier(identifier string) (string, error) {
+	if !c.logPrivacyMode {
+		return RedactIdentifier(identifier), nil
+	}
+	key := c.logPrivacyKey
+	if len(key) == 0 {
+		var err error
+		if key, err = defaultLogPrivacyKey(); err != nil {
+			return logIdentifierUnavailable, err
+		}
+	}
+	return HashIdentifier(key, identifier), nil
+}
+
+// logLookup will log the result of the lookup
+func (c *Client) logLookup(ctx context.Context, response *GetAddressResponse, err error, duration time.Duration) {
+	if c.logger == nil {
+		return
+	}
+	fields := map[string]interface{}{
+		"duration_ms": durationMS(duration),
+		"outcome":     string(OutcomeOf(err)),
+	}
+	if response != nil {
+		fields["cache_hit"] = response.CacheHit
+		if response.LastRequest != nil {
+			fields["attempts"] = response.LastRequest.Attempts
+			fields["status"] = response.LastRequest.StatusCode
+		}
+	}
+	if err != nil {
+		fields["error"] = logError(err)
+		c.log(ctx, LogLevelWarn, "lookup failed", fields)
+		return
+	}
+	c.log(ctx, LogLevelInfo, "lookup finished", fields)
+}
+
+// logRequest will log the finished attempt of the request
+func (c *Client) logRequest(ctx context.Context, event *RequestEvent) {
+	if c.logger == nil {
+		return
+	}
+	fields := map[string]interface{}{
+		"attempt":     event.Attempt,
+		"duration_ms": durationMS(event.Duration),
+		"host":        event.Host,
+		"method":      event.Method,
+		"status":      event.StatusCode,
+		"upstream":    event.Upstream,
+	}
+	if event.Err != nil {
+		fields["error"] = logError(event.Err)
+	}
+	c.log(ctx, LogLevelDebug, "request finished", fields)
+}
+
+// logRetry will log the retry of a request after a failed attempt
+func (c *Client) logRetry(ctx context.Context, req *http.Request, attempt int, resp *http.Response, err error) {
+	if c.logger == nil {
+		return
+	}
+	fields := map[string]interface{}{
+		"attempt": attempt,
+		"host":    req.URL.Host,
+		"method":  req.Method,
+	}
+	if err != nil {
+		fields["error"] = logError(err)
+	} else if resp != nil {
+		fields["status"] = resp.StatusCode
+	}
+	c.log(ctx, LogLevelWarn, "retrying request", fields)
+}
+
+// logDecodeFailure will log the decode failure of a response (if the error is a decode failure)
+func (c *Client) logDecodeFailure(ctx context.Context, lastRequest *LastRequest, err error) {
+	if c.logger == nil || !errors.Is(err, ErrDecodeFailure) {
+		return
+	}
+	fields := map[string]interface{}{"error": logError(err)}
+	if lastRequest != nil {
+		fields["method"] = lastRequest.Method
+		fields["status"] = lastRequest.StatusCode
+	}
+	c.log(ctx, LogLevelError, "decode failure", fields)
+}
+
+// logError returns the message of the error for the logs
+//
+// Upstream messages can repeat the identifier ("$mrz not found") and request errors include the URL,
+// so only the kind of a ResolveError and the underlying error (without the URL) are logged
+func logError(err error) string {
+	var resolveErr *ResolveError
+	if !errors.As(err, &resolveErr) {
+		return logTransportError(err)
+	} else if resolveErr.Err == nil {
+		return resolveErr.Kind.Error()
+	} else if errors.Is(resolveErr, ErrTransportFailure) {
+		return resolveErr.Kind.Error() + ": " + logTransportError(resolveErr.Err)
+	}
+	err = resolveErr.Err
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return resolveErr.Kind.Error() + ": " + err.Error()
+}
+
+// logTransportError returns the message of a transport error for the logs
+//
+// Only the error under the *url.Error (the network error, without the URL) and context errors are logged,
+// the text of any other error can contain the URL, so only its type is logged
+func logTransportError(err error) string {
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &urlErr):
+		return urlErr.Err.Error()
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err.Error()
+	default:
+		return "transport error (" + fmt.Sprintf("%T", err) + ")"
+	}
+}
+
+// cacheErrorType returns the type of the error from the cache for the logs
+//
+// The message of the error is not logged, it can contain the key (and the paymail in it)
+func cacheErrorType(err error) string {
+	return fmt.Sprintf("%T", err)
+}
+
+// RedactIdentifier returns the identifier with all but the first character (and the domain) masked
+//
+// Example: mrz@handcash.io -> m***@handcash.io, $mrz -> $***, 1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa -> 1***
+func RedactIdentifier(identifier string) string {
+	identifier = strings.TrimSpace(identifier)
+	if len(identifier) == 0 {
+		return ""
+	}
+	first, _ := utf8.DecodeRuneInString(identifier)
+	if at := strings.LastIndex(identifier, "@"); at > 0 {
+		return string(first) + "***" + identifier[at:]
+	}
+	return string(first) + "***"
+}
+
+// HashIdentifier returns a keyed hash (HMAC-SHA256) of the (lowercase) identifier, used by the privacy mode of the logs
+//
+// The hash is stable for the key, so the lookups of the same handle or paymail can still be correlated.
+// Handles have little entropy, so without the key the hashes cannot be reversed with a list of known handles.
+func HashIdentifier(key []byte, identifier string) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(strings.ToLower(strings.TrimSpace(identifier))))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// logIdentifierUnavailable is the identifier in the logs when the privacy mode has no key
+const logIdentifierUnavailable = "hmac:unavailable"
+
+// The key of the identifier hashes when LogPrivacyKey is not set (random per process, created on first use)
+var (
+	logPrivacyKey     []byte
+	logPrivacyKeyErr  error
+	logPrivacyKeyOnce sync.Once
+)
+
+// defaultLogPrivacyKey returns the random key of the identifier hashes (created the first time it is used)
+func defaultLogPrivacyKey() ([]byte, error) {
+	logPrivacyKeyOnce.Do(func() {
+		logPrivacyKey, logPrivacyKeyErr = newLogPrivacyKey(rand.Reader)
+	})
+	return logPrivacyKey, logPrivacyKeyErr
+}
+
+// newLogPrivacyKey returns a random key for the identifier hashes
+func newLogPrivacyKey(random io.Reader) ([]byte, error) {
+	key := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(random, key); err != nil {
+		return nil, fmt.Errorf("failed to create the log privacy key: %w", err)
+	}
+	return key, nil
+}
+
+// durationMS returns the duration in milliseconds
+func durationMS(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
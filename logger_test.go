@@ -0,0 +1,387 @@
+package polynym
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/gojektech/heimdall/v6"
+)
+
+// TestRedactIdentifier will test the method RedactIdentifier()
+func TestRedactIdentifier(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		input    string
+		expected string
+	}{
+		{"mrz@handcash.io", "m***@handcash.io"},
+		{" MrZ@HandCash.io ", "M***@HandCash.io"},
+		{"$mrz", "$***"},
+		{"1mrz", "1***"},
+		{"@833", "@***"},
+		{"a@b@example.com", "a***@example.com"},
+		{"1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa", "1***"},
+		{"élan@example.com", "é***@example.com"},
+		{"", ""},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if output := RedactIdentifier(test.input); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expected, output)
+		}
+	}
+}
+
+// TestHashIdentifier will test the method HashIdentifier()
+func TestHashIdentifier(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("secret")
+	hash := HashIdentifier(key, "mrz@handcash.io")
+	if !strings.HasPrefix(hash, "hmac:") || len(hash) != len("hmac:")+16 || strings.Contains(hash, "mrz") {
+		t.Fatalf("%s Failed: unexpected hash [%s]", t.Name(), hash)
+	} else if HashIdentifier(key, " MRZ@handcash.io ") != hash {
+		t.Fatalf("%s Failed: expected the same hash for the same paymail", t.Name())
+	} else if HashIdentifier(key, "other@handcash.io") == hash {
+		t.Fatalf("%s Failed: expected a different hash for another paymail", t.Name())
+	} else if HashIdentifier([]byte("other"), "mrz@handcash.io") == hash {
+		t.Fatalf("%s Failed: expected a different hash for another key", t.Name())
+	}
+
+	// The hash is not the plain (unkeyed) hash of the paymail
+	sum := sha256.Sum256([]byte("mrz@handcash.io"))
+	if strings.HasSuffix(hash, hex.EncodeToString(sum[:8])) {
+		t.Fatalf("%s Failed: expected a keyed hash [%s]", t.Name(), hash)
+	}
+
+	// The default key is random (and the same for every call)
+	defaultKey, err := defaultLogPrivacyKey()
+	if err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	} else if len(defaultKey) != sha256.Size || bytes.Equal(defaultKey, make([]byte, sha256.Size)) {
+		t.Fatalf("%s Failed: expected a random default key", t.Name())
+	} else if sameKey, _ := defaultLogPrivacyKey(); !bytes.Equal(sameKey, defaultKey) {
+		t.Fatalf("%s Failed: expected the same default key", t.Name())
+	}
+
+	// The random source fails
+	if _, err = newLogPrivacyKey(iotest.ErrReader(errors.New("no entropy"))); err == nil {
+		t.Fatalf("%s Failed: expected an error", t.Name())
+	}
+}
+
+// TestLogError will test the method logError()
+func TestLogError(t *testing.T) {
+	t.Parallel()
+
+	// The text of an unknown transport error can contain the URL
+	textErr := errors.New(`Get "https://api.polynym.io/getAddress/mrz@handcash.io": connection refused`)
+
+	// Create the list of tests
+	var tests = []struct {
+		input    error
+		expected string
+	}{
+		{newResolveError(ErrNotFound, nil, "$mrz not found", nil), ErrNotFound.Error()},
+		{newResolveError(ErrTransportFailure, nil, "", textErr), ErrTransportFailure.Error() + ": transport error (*errors.errorString)"},
+		{newResolveError(ErrTransportFailure, nil, "", context.Canceled), ErrTransportFailure.Error() + ": " + context.Canceled.Error()},
+		{newResolveError(ErrInvalidInput, nil, "invalid 1handle: 1", ErrInvalidChecksum), ErrInvalidInput.Error() + ": " + ErrInvalidChecksum.Error()},
+		{textErr, "transport error (*errors.errorString)"},
+		{context.DeadlineExceeded, context.DeadlineExceeded.Error()},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if output := logError(test.input); output != test.expected {
+			t.Errorf("%s Failed: [%v] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expected, output)
+		}
+	}
+}
+
+// TestGetAddress_LogTransportError will make sure the transport errors of the heimdall client do not log the URL
+func TestGetAddress_LogTransportError(t *testing.T) {
+	t.Parallel()
+
+	// A port without a server
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	}
+	address := listener.Addr().String()
+	_ = listener.Close()
+
+	var buf bytes.Buffer
+	options := ClientDefaultOptions()
+	options.APIEndpoint = "http://" + address
+	options.Logger = NewJSONLogger(&buf, LogLevelDebug)
+	options.LogPrivacyMode = true
+	options.RequestRetryCount = 1
+	client := NewClient(options)
+
+	if _, err = client.GetAddress("secretperson@handcash.io"); !errors.Is(err, ErrTransportFailure) {
+		t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrTransportFailure, err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var errorLines int
+	for _, line := range lines {
+		if strings.Contains(line, "secretperson") || strings.Contains(line, "/getAddress/") {
+			t.Errorf("%s Failed: the log line contains the identifier [%s]", t.Name(), line)
+		} else if strings.Contains(line, `"error":"`) {
+			errorLines++
+			if !strings.Contains(line, "connect") {
+				t.Errorf("%s Failed: expected the network error in the log line [%s]", t.Name(), line)
+			}
+		}
+	}
+	if errorLines != 4 { // 2 requests, 1 retry and the lookup
+		t.Fatalf("%s Failed: expected [%d] log lines with an error, received: [%d] %v", t.Name(), 4, errorLines, lines)
+	}
+}
+
+// TestParseLogLevel will test the method ParseLogLevel()
+func TestParseLogLevel(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		input         string
+		expected      LogLevel
+		expectedError bool
+	}{
+		{"debug", LogLevelDebug, false},
+		{"INFO", LogLevelInfo, false},
+		{"Warn", LogLevelWarn, false},
+		{"error", LogLevelError, false},
+		{"verbose", LogLevelDebug, true},
+		{"", LogLevelDebug, true},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if output, err := ParseLogLevel(test.input); (err != nil) != test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted, unexpected error [%v]", t.Name(), test.input, err)
+		} else if output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expected, output)
+		} else if err == nil && output.String() != strings.ToLower(test.input) {
+			t.Errorf("%s Failed: [%s] inputted, unexpected name [%s]", t.Name(), test.input, output.String())
+		}
+	}
+	if name := LogLevel(10).String(); name != "level(10)" {
+		t.Errorf("%s Failed: unexpected name [%s]", t.Name(), name)
+	}
+}
+
+// TestJSONLogger will test the JSONLogger output
+func TestJSONLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf, LogLevelInfo)
+	logger.Log(context.Background(), LogLevelDebug, "ignored", map[string]interface{}{"attempt": 1})
+	logger.Log(context.Background(), LogLevelWarn, "retrying request", map[string]interface{}{"attempt": 2, "message": "overridden"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	entry := make(map[string]interface{})
+	if len(lines) != 1 {
+		t.Fatalf("%s Failed: expected [%d] entry, received: [%d]", t.Name(), 1, len(lines))
+	} else if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	} else if entry["level"] != "warn" || entry["message"] != "retrying request" || entry["attempt"] != float64(2) {
+		t.Fatalf("%s Failed: unexpected entry [%v]", t.Name(), entry)
+	} else if _, err = time.Parse(time.RFC3339Nano, fmt.Sprint(entry["time"])); err != nil {
+		t.Fatalf("%s Failed: unexpected time [%v]", t.Name(), entry["time"])
+	}
+}
+
+// findLogEntry returns the first entry with the message (or nil)
+func findLogEntry(entries []*mockLogEntry, message string) *mockLogEntry {
+	for _, entry := range entries {
+		if entry.message == message {
+			return entry
+		}
+	}
+	return nil
+}
+
+// TestGetAddress_Logger will test the entries logged by the client
+func TestGetAddress_Logger(t *testing.T) {
+	t.Parallel()
+
+	t.Run("lookup with retries", func(t *testing.T) {
+		logger := new(mockLogger)
+		client := &Client{
+			httpClient: &mockHTTPUnavailable{},
+			logger:     logger,
+			retrier:    heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Millisecond, 0)),
+			retryCount: 2,
+			UserAgent:  defaultUserAgent,
+		}
+		_, _ = client.GetAddress("1mrz")
+
+		var messages []string
+		entries := logger.reset()
+		for _, entry := range entries {
+			messages = append(messages, entry.message)
+			if entry.fields["identifier"] != "m***@relayx.io" || entry.fields["identifier_type"] != "relayx" {
+				t.Errorf("%s Failed: [%s] missing identifier fields [%v]", t.Name(), entry.message, entry.fields)
+			}
+		}
+		expected := "lookup started,request started,request finished,retrying request,request started,request finished," +
+			"retrying request,request started,request finished,lookup failed"
+		if strings.Join(messages, ",") != expected {
+			t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), expected, strings.Join(messages, ","))
+		}
+
+		// Check the fields
+		if entry := findLogEntry(entries, "retrying request"); entry.level != LogLevelWarn || entry.fields["attempt"] != 2 ||
+			entry.fields["status"] != http.StatusServiceUnavailable {
+			t.Errorf("%s Failed: unexpected retry entry [%v]", t.Name(), entry.fields)
+		}
+		if entry := findLogEntry(entries, "request finished"); entry.level != LogLevelDebug || entry.fields["host"] != "api.polynym.io" ||
+			entry.fields["upstream"] != UpstreamPolynym || entry.fields["status"] != http.StatusServiceUnavailable {
+			t.Errorf("%s Failed: unexpected request entry [%v]", t.Name(), entry.fields)
+		}
+		if entry := findLogEntry(entries, "lookup failed"); entry.level != LogLevelWarn || entry.fields["outcome"] != "upstream_unavailable" ||
+			entry.fields["attempts"] != 3 || entry.fields["error"] != ErrUpstreamUnavailable.Error() {
+			t.Errorf("%s Failed: unexpected lookup entry [%v]", t.Name(), entry.fields)
+		} else if _, ok := entry.fields["duration_ms"].(float64); !ok {
+			t.Errorf("%s Failed: missing duration [%v]", t.Name(), entry.fields)
+		}
+	})
+
+	t.Run("privacy mode", func(t *testing.T) {
+		logger := new(mockLogger)
+		client := &Client{httpClient: &mockHTTP{}, logger: logger, logPrivacyMode: true}
+		if _, err := client.GetAddress("$MrZ"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		entries := logger.reset()
+		entry := findLogEntry(entries, "lookup finished")
+		defaultKey, _ := defaultLogPrivacyKey()
+		if entry == nil || entry.fields["identifier"] != HashIdentifier(defaultKey, "mrz@handcash.io") || entry.fields["outcome"] != "success" {
+			t.Fatalf("%s Failed: unexpected entry [%v]", t.Name(), entry)
+		}
+
+		// With a key from the options
+		options := ClientDefaultOptions()
+		options.Logger = logger
+		options.LogPrivacyKey = []byte("secret")
+		options.LogPrivacyMode = true
+		keyed := NewClient(options)
+		keyed.httpClient = &mockHTTP{}
+		if _, err := keyed.GetAddress("$MrZ"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if entry = findLogEntry(logger.reset(), "lookup finished"); entry == nil ||
+			entry.fields["identifier"] != HashIdentifier([]byte("secret"), "mrz@handcash.io") {
+			t.Fatalf("%s Failed: unexpected entry [%v]", t.Name(), entry)
+		}
+		for _, entry = range entries {
+			if strings.Contains(strings.ToLower(fmt.Sprint(entry.fields)), "mrz") {
+				t.Errorf("%s Failed: [%s] the identifier should be hashed [%v]", t.Name(), entry.message, entry.fields)
+			}
+		}
+	})
+
+	t.Run("successful lookup", func(t *testing.T) {
+		logger := new(mockLogger)
+		client := &Client{httpClient: &mockHTTP{}, logger: logger}
+		if _, err := client.GetAddress("16ZqP5Tb22KJuvSAbjNkoiZs13mmRmexZA"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		if entry := findLogEntry(logger.reset(), "lookup finished"); entry == nil || entry.level != LogLevelInfo ||
+			entry.fields["identifier"] != "1***" || entry.fields["outcome"] != "success" || entry.fields["status"] != http.StatusOK {
+			t.Fatalf("%s Failed: unexpected entry [%v]", t.Name(), entry)
+		}
+	})
+
+	t.Run("cache events", func(t *testing.T) {
+		logger := new(mockLogger)
+		client, _ := newMockCacheClient(time.Minute, 0, 10)
+		client.logger = logger
+		for i := 0; i < 2; i++ {
+			if _, err := client.GetAddress("$mr-z"); err != nil {
+				t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+			}
+		}
+		entries := logger.reset()
+		if findLogEntry(entries, "cache miss") == nil || findLogEntry(entries, "cache hit") == nil {
+			t.Fatalf("%s Failed: expected a cache miss and hit", t.Name())
+		} else if entry := findLogEntry(entries, "cache stored"); entry == nil || entry.fields["ttl_ms"] != float64(60000) ||
+			entry.fields["identifier"] != "m***@handcash.io" {
+			t.Fatalf("%s Failed: unexpected cache entry [%v]", t.Name(), entry)
+		}
+
+		// Errors from the cache
+		client.cache = &mockCacheError{}
+		if _, err := client.GetAddress("$mr-z"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		var operations []string
+		for _, entry := range logger.reset() {
+			if entry.message == "cache error" && entry.level == LogLevelWarn {
+				operations = append(operations, fmt.Sprint(entry.fields["operation"]))
+				if strings.Contains(fmt.Sprint(entry.fields), "mr-z@") {
+					t.Errorf("%s Failed: the cache error should not contain the key [%v]", t.Name(), entry.fields)
+				}
+			}
+		}
+		if strings.Join(operations, ",") != "get,set" {
+			t.Fatalf("%s Failed: expected the get and set errors, received: %v", t.Name(), operations)
+		}
+	})
+
+	t.Run("decode failures", func(t *testing.T) {
+		logger := new(mockLogger)
+		client := &Client{httpClient: &mockHTTP{}, logger: logger}
+		_, _ = client.GetAddress("bad-poly-json@example.com")
+		if entry := findLogEntry(logger.reset(), "decode failure"); entry == nil || entry.level != LogLevelError ||
+			entry.fields["status"] != http.StatusOK || entry.fields["identifier"] != "b***@example.com" {
+			t.Fatalf("%s Failed: unexpected entry [%v]", t.Name(), entry)
+		}
+
+		// Paymail providers
+		_, client = newMockPaymailServer(t, basicCapabilities, map[string]http.HandlerFunc{
+			"/api/v1/bsvalias/address/": func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("not-json"))
+			},
+		})
+		client.logger = logger
+		_, _ = client.ResolvePaymail(context.Background(), "mrz@handcash.io")
+		if entry := findLogEntry(logger.reset(), "decode failure"); entry == nil || entry.fields["method"] != http.MethodPost {
+			t.Fatalf("%s Failed: unexpected entry [%v]", t.Name(), entry)
+		}
+	})
+}
+
+// ExampleNewJSONLogger example using NewJSONLogger()
+func ExampleNewJSONLogger() {
+	var buf bytes.Buffer
+	client := &Client{httpClient: &mockHTTP{}, logger: NewJSONLogger(&buf, LogLevelInfo)}
+	_, _ = client.GetAddress("1mrz")
+
+	entry := make(map[string]interface{})
+	_ = json.Unmarshal(buf.Bytes(), &entry)
+	fmt.Println(entry["message"], entry["identifier"], entry["outcome"])
+	// Output:lookup finished m***@relayx.io success
+}
+
+// BenchmarkRedactIdentifier benchmarks the method RedactIdentifier()
+func BenchmarkRedactIdentifier(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = RedactIdentifier("mrz@handcash.io")
+	}
+}
@@ -0,0 +1,318 @@
+package polynym
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcome is the class of the result of a lookup (used for the metrics)
+type Outcome string
+
+// Outcomes of a lookup (see OutcomeOf)
+const (
+	OutcomeSuccess             Outcome = "success"              // The address was resolved
+	OutcomeNotFound            Outcome = "not_found"            // ErrNotFound
+	OutcomeInvalidInput        Outcome = "invalid_input"        // ErrInvalidInput
+	OutcomeRateLimited         Outcome = "rate_limited"         // ErrRateLimited
+	OutcomeUpstreamUnavailable Outcome = "upstream_unavailable" // ErrUpstreamUnavailable
+	OutcomeDecodeFailure       Outcome = "decode_failure"       // ErrDecodeFailure
+	OutcomeTransportFailure    Outcome = "transport_failure"    // ErrTransportFailure
+	OutcomeCanceled            Outcome = "canceled"             // The context was canceled
+	OutcomeTimeout             Outcome = "timeout"              // The deadline of the context passed
+	OutcomeError               Outcome = "error"                // Any other error
+)
+
+// Upstreams of a request (see RequestEvent)
+const (
+	UpstreamPaymail = "paymail" // A paymail provider (native resolution, capabilities, P2P, etc)
+	UpstreamPolynym = "polynym" // The Polynym API
+)
+
+// prometheusContentType is the content type of the Prometheus text exposition format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultDurationBuckets are the default histogram buckets (in seconds) of the PrometheusObserver
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// ResolutionEvent is a finished lookup (GetAddress, GetAddresses, etc) reported to the Observer
+type ResolutionEvent struct {
+	Attempts       int            // Attempts is the number of times the last request was sent (0 for cache hits and addresses)
+	CacheHit       bool           // CacheHit is true if the result came from the cache
+	Duration       time.Duration  // Duration is the total time of the lookup
+	Err            error          // Err is the error of the lookup (nil on success)
+	IdentifierType IdentifierType // IdentifierType is the type of the input (paymail, handcash, etc)
+	Outcome        Outcome        // Outcome is the class of the result (success, not_found, etc)
+	StatusCode     int            // StatusCode is the status of the last request (0 if no response)
+}
+
+// RequestEvent is a single HTTP request (every attempt, including retries) reported to the Observer
+type RequestEvent struct {
+	Attempt    int           // Attempt is the number of the attempt (1 is the first request, 2+ are retries)
+	Duration   time.Duration // Duration is the time until the response headers (or the error)
+	Err        error         // Err is the transport error (nil if a response was received)
+	Host       string        // Host is the host of the request
+	Method     string        // Method is the HTTP method used
+	StatusCode int           // StatusCode is the status of the response (0 if no response)
+	Timings    Timings       // Timings are the phases of the request (DNS, connect, TLS handshake, etc)
+	Upstream   string        // Upstream is UpstreamPolynym or UpstreamPaymail
+}
+
+// OutcomeOf returns the outcome class of the error (OutcomeSuccess if nil)
+func OutcomeOf(err error) Outcome {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	case errors.Is(err, context.Canceled):
+		return OutcomeCanceled
+	case errors.Is(err, ErrNotFound):
+		return OutcomeNotFound
+	case errors.Is(err, ErrInvalidInput):
+		return OutcomeInvalidInput
+	case errors.Is(err, ErrRateLimited):
+		return OutcomeRateLimited
+	case errors.Is(err, ErrUpstreamUnavailable):
+		return OutcomeUpstreamUnavailable
+	case errors.Is(err, ErrDecodeFailure):
+		return OutcomeDecodeFailure
+	case errors.Is(err, ErrTransportFailure):
+		return OutcomeTransportFailure
+	}
+	return OutcomeError
+}
+
+// observeResolution will report the lookup to the observer (if set)
+func (c *Client) observeResolution(idType IdentifierType, response *GetAddressResponse, err error, duration time.Duration) {
+	if c.observer == nil {
+		return
+	}
+	event := &ResolutionEvent{
+		Duration:       duration,
+		Err:            err,
+		IdentifierType: idType,
+		Outcome:        OutcomeOf(err),
+	}
+	if response != nil {
+		event.CacheHit = response.CacheHit
+		if response.LastRequest != nil {
+			event.Attempts = response.LastRequest.Attempts
+			event.StatusCode = response.LastRequest.StatusCode
+		}
+	}
+	c.observer.ObserveResolution(event)
+}
+
+// newRequestEvent will create the event for the attempt of the request
+func (c *Client) newRequestEvent(req *http.Request, trace *AttemptTrace, err error) *RequestEvent {
+	return &RequestEvent{
+		Attempt:    trace.Attempt,
+		Duration:   trace.Timings.Total,
+		Err:        err,
+		Host:       req.URL.Host,
+		Method:     req.Method,
+		StatusCode: trace.StatusCode,
+		Timings:    trace.Timings,
+		Upstream:   c.upstream(req),
+	}
+}
+
+// upstream returns the upstream of the request (UpstreamPolynym or UpstreamPaymail)
+func (c *Client) upstream(req *http.Request) string {
+	if strings.HasPrefix(req.URL.String(), c.endpoint()+"/") {
+		return UpstreamPolynym
+	}
+	return UpstreamPaymail
+}
+
+// PrometheusObserver is an Observer that keeps counters and histograms in memory
+//
+// The metrics are exposed in the Prometheus text exposition format with WriteMetrics() or
+// by serving the observer (it is an http.Handler, mount it at /metrics):
+//
+//	polynym_resolutions_total{type,outcome}            lookups by identifier type and outcome
+//	polynym_resolution_cache_hits_total{type}          lookups served from the cache
+//	polynym_resolution_attempts_total{type}            requests sent for the lookups (including retries)
+//	polynym_resolution_duration_seconds{type}          histogram of the lookup durations
+//	polynym_http_requests_total{upstream,method,code}  requests by upstream and status ("error" if no response)
+//	polynym_http_request_duration_seconds{upstream}    histogram of the request durations
+type PrometheusObserver struct {
+	buckets             []float64
+	mu                  sync.Mutex
+	requestDurations    map[string]*histogram // by upstream
+	requests            map[string]float64    // by upstream, method and code
+	resolutionAttempts  map[string]float64    // by type
+	resolutionCacheHits map[string]float64    // by type
+	resolutionDurations map[string]*histogram // by type
+	resolutions         map[string]float64    // by type and outcome
+}
+
+// histogram is a cumulative histogram of durations (in seconds)
+type histogram struct {
+	counts []uint64 // counts for each bucket (not cumulative)
+	count  uint64
+	sum    float64
+}
+
+// NewPrometheusObserver will create a new observer with the histogram buckets (in seconds)
+//
+// If no buckets are given the DefaultDurationBuckets are used
+func NewPrometheusObserver(buckets ...float64) *PrometheusObserver {
+	if len(buckets) == 0 {
+		buckets = DefaultDurationBuckets
+	}
+	sorted := make([]float64, 0, len(buckets))
+	for _, bucket := range buckets {
+		if !math.IsInf(bucket, 1) { // The +Inf bucket is always added
+			sorted = append(sorted, bucket)
+		}
+	}
+	sort.Float64s(sorted)
+	return &PrometheusObserver{
+		buckets:             sorted,
+		requestDurations:    make(map[string]*histogram),
+		requests:            make(map[string]float64),
+		resolutionAttempts:  make(map[string]float64),
+		resolutionCacheHits: make(map[string]float64),
+		resolutionDurations: make(map[string]*histogram),
+		resolutions:         make(map[string]float64),
+	}
+}
+
+// ObserveResolution will count the lookup
+func (p *PrometheusObserver) ObserveResolution(event *ResolutionEvent) {
+	typeLabels := labels("type", event.IdentifierType.String())
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resolutions[labels("type", event.IdentifierType.String(), "outcome", string(event.Outcome))]++
+	p.resolutionAttempts[typeLabels] += float64(event.Attempts)
+	if event.CacheHit {
+		p.resolutionCacheHits[typeLabels]++
+	}
+	p.observe(p.resolutionDurations, typeLabels, event.Duration)
+}
+
+// ObserveRequest will count the request
+func (p *PrometheusObserver) ObserveRequest(event *RequestEvent) {
+	code := "error"
+	if event.StatusCode > 0 {
+		code = strconv.Itoa(event.StatusCode)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests[labels("upstream", event.Upstream, "method", event.Method, "code", code)]++
+	p.observe(p.requestDurations, labels("upstream", event.Upstream), event.Duration)
+}
+
+// observe will add the duration to the histogram for the labels (the lock must be held)
+func (p *PrometheusObserver) observe(histograms map[string]*histogram, key string, duration time.Duration) {
+	h, ok := histograms[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(p.buckets))}
+		histograms[key] = h
+	}
+	seconds := duration.Seconds()
+	if i := sort.SearchFloat64s(p.buckets, seconds); i < len(p.buckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// WriteMetrics will write all the metrics in the Prometheus text exposition format
+func (p *PrometheusObserver) WriteMetrics(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	p.mu.Lock()
+	writeCounter(buf, "polynym_resolutions_total", "Total number of lookups by identifier type and outcome.", p.resolutions)
+	writeCounter(buf, "polynym_resolution_cache_hits_total", "Total number of lookups served from the cache.", p.resolutionCacheHits)
+	writeCounter(buf, "polynym_resolution_attempts_total", "Total number of requests sent for the lookups (including retries).", p.resolutionAttempts)
+	p.writeHistogram(buf, "polynym_resolution_duration_seconds", "Duration of the lookups in seconds.", p.resolutionDurations)
+	writeCounter(buf, "polynym_http_requests_total", "Total number of HTTP requests by upstream, method and status code.", p.requests)
+	p.writeHistogram(buf, "polynym_http_request_duration_seconds", "Duration of the HTTP requests in seconds.", p.requestDurations)
+	p.mu.Unlock()
+	return buf.Flush()
+}
+
+// ServeHTTP will serve the metrics in the Prometheus text exposition format
+func (p *PrometheusObserver) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	_ = p.WriteMetrics(w)
+}
+
+// writeHistogram will write the histograms (cumulative buckets, sum and count) sorted by labels
+func (p *PrometheusObserver) writeHistogram(w io.Writer, name, help string, histograms map[string]*histogram) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	keys := make([]string, 0, len(histograms))
+	for key := range histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		h := histograms[key]
+		var cumulative uint64
+		for i, bucket := range p.buckets {
+			cumulative += h.counts[i]
+			_, _ = fmt.Fprintf(w, "%s_bucket{%s} %d\n", name, joinLabels(key, labels("le", formatFloat(bucket))), cumulative)
+		}
+		_, _ = fmt.Fprintf(w, "%s_bucket{%s} %d\n", name, joinLabels(key, labels("le", "+Inf")), h.count)
+		_, _ = fmt.Fprintf(w, "%s_sum{%s} %s\n", name, key, formatFloat(h.sum))
+		_, _ = fmt.Fprintf(w, "%s_count{%s} %d\n", name, key, h.count)
+	}
+}
+
+// writeCounter will write the counters sorted by labels
+func writeCounter(w io.Writer, name, help string, counters map[string]float64) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([]string, 0, len(counters))
+	for key := range counters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(w, "%s{%s} %s\n", name, key, formatFloat(counters[key]))
+	}
+}
+
+// labels will format the name and value pairs as Prometheus labels: name="value",...
+func labels(pairs ...string) string {
+	var builder strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(pairs[i])
+		builder.WriteString(`="`)
+		builder.WriteString(labelEscaper.Replace(pairs[i+1]))
+		builder.WriteByte('"')
+	}
+	return builder.String()
+}
+
+// labelEscaper escapes the label values (backslash, double quote and line feed)
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// joinLabels will join the formatted labels
+func joinLabels(a, b string) string {
+	if len(a) == 0 {
+		return b
+	}
+	return a + "," + b
+}
+
+// formatFloat will format the value for the text exposition format
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,227 @@
+package polynym
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gojektech/heimdall/v6"
+)
+
+// TestOutcomeOf will test the method OutcomeOf()
+func TestOutcomeOf(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		input    error
+		expected Outcome
+	}{
+		{nil, OutcomeSuccess},
+		{newResolveError(ErrNotFound, nil, "", nil), OutcomeNotFound},
+		{newResolveError(ErrInvalidInput, nil, "", nil), OutcomeInvalidInput},
+		{newResolveError(ErrRateLimited, nil, "", nil), OutcomeRateLimited},
+		{newResolveError(ErrUpstreamUnavailable, nil, "", nil), OutcomeUpstreamUnavailable},
+		{newResolveError(ErrDecodeFailure, nil, "", nil), OutcomeDecodeFailure},
+		{newResolveError(ErrTransportFailure, nil, "", errors.New("connection refused")), OutcomeTransportFailure},
+		{newResolveError(ErrTransportFailure, nil, "", context.Canceled), OutcomeCanceled},
+		{newResolveError(ErrTransportFailure, nil, "", context.DeadlineExceeded), OutcomeTimeout},
+		{newResolveError(ErrCapabilityNotFound, nil, "", nil), OutcomeError},
+		{errors.New("other"), OutcomeError},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if output := OutcomeOf(test.input); output != test.expected {
+			t.Errorf("%s Failed: [%v] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expected, output)
+		}
+	}
+}
+
+// TestGetAddress_Observer will test the events reported to the observer
+func TestGetAddress_Observer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("lookups", func(t *testing.T) {
+		observer := new(mockObserver)
+		client := &Client{httpClient: &mockHTTP{}, observer: observer, UserAgent: defaultUserAgent}
+
+		// Create the list of tests
+		var tests = []struct {
+			input            string
+			expectedType     IdentifierType
+			expectedOutcome  Outcome
+			expectedStatus   int
+			expectedAttempts int
+		}{
+			{"1mrz", IdentifierRelayX, OutcomeSuccess, http.StatusOK, 1},
+			{"$", IdentifierHandCash, OutcomeInvalidInput, http.StatusBadRequest, 0},
+			{"16ZqP5Tb22KJuvSAbjNkoiZs13mmRmexZA", IdentifierAddress, OutcomeSuccess, http.StatusOK, 0},
+			{"bad@paymailaddress.com", IdentifierPaymail, OutcomeNotFound, http.StatusBadRequest, 1},
+			{"rate-limited@example.com", IdentifierPaymail, OutcomeRateLimited, http.StatusTooManyRequests, 1},
+			{"error@example.com", IdentifierPaymail, OutcomeTransportFailure, http.StatusBadRequest, 1},
+		}
+
+		// Test all
+		for _, test := range tests {
+			_, _ = client.GetAddress(test.input)
+			requests, resolutions := observer.reset()
+			if len(resolutions) != 1 {
+				t.Fatalf("%s Failed: [%s] inputted and 1 resolution expected, received: [%d]", t.Name(), test.input, len(resolutions))
+			} else if len(requests) != test.expectedAttempts {
+				t.Fatalf("%s Failed: [%s] inputted and [%d] requests expected, received: [%d]", t.Name(), test.input, test.expectedAttempts, len(requests))
+			}
+			event := resolutions[0]
+			if event.IdentifierType != test.expectedType || event.Outcome != test.expectedOutcome ||
+				event.StatusCode != test.expectedStatus || event.Attempts != test.expectedAttempts || event.CacheHit {
+				t.Errorf("%s Failed: [%s] inputted, unexpected event [%+v]", t.Name(), test.input, event)
+			} else if (event.Err == nil) != (test.expectedOutcome == OutcomeSuccess) || event.Duration <= 0 {
+				t.Errorf("%s Failed: [%s] inputted, unexpected error or duration [%+v]", t.Name(), test.input, event)
+			}
+			for _, request := range requests {
+				if request.Upstream != UpstreamPolynym || request.Method != http.MethodGet || request.Host != "api.polynym.io" {
+					t.Errorf("%s Failed: [%s] inputted, unexpected request [%+v]", t.Name(), test.input, request)
+				} else if request.Err == nil && request.StatusCode != test.expectedStatus {
+					t.Errorf("%s Failed: [%s] inputted, unexpected request status [%+v]", t.Name(), test.input, request)
+				} else if request.Err != nil && (request.StatusCode != 0 || test.expectedOutcome != OutcomeTransportFailure) {
+					t.Errorf("%s Failed: [%s] inputted, unexpected request status [%+v]", t.Name(), test.input, request)
+				}
+			}
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		observer := new(mockObserver)
+		client := &Client{
+			httpClient: &mockHTTPUnavailable{},
+			observer:   observer,
+			retrier:    heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Millisecond, 0)),
+			retryCount: 2,
+			UserAgent:  defaultUserAgent,
+		}
+		response, err := client.GetAddress("1mrz")
+		if !errors.Is(err, ErrUpstreamUnavailable) || response.LastRequest.Attempts != 3 {
+			t.Fatalf("%s Failed: unexpected result [%v] [%v]", t.Name(), response.LastRequest, err)
+		}
+		requests, resolutions := observer.reset()
+		if len(requests) != 3 || requests[0].Attempt != 1 || requests[2].Attempt != 3 {
+			t.Fatalf("%s Failed: expected 3 request events, received: [%d]", t.Name(), len(requests))
+		} else if len(resolutions) != 1 || resolutions[0].Attempts != 3 || resolutions[0].Outcome != OutcomeUpstreamUnavailable {
+			t.Fatalf("%s Failed: unexpected resolution events [%v]", t.Name(), resolutions)
+		}
+	})
+
+	t.Run("cache hits", func(t *testing.T) {
+		observer := new(mockObserver)
+		client, _ := newMockCacheClient(time.Minute, 0, 10)
+		client.observer = observer
+		for i := 0; i < 2; i++ {
+			if _, err := client.GetAddress("$mr-z"); err != nil {
+				t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+			}
+		}
+		if requests, resolutions := observer.reset(); len(requests) != 1 || len(resolutions) != 2 {
+			t.Fatalf("%s Failed: unexpected events [%d] [%d]", t.Name(), len(requests), len(resolutions))
+		} else if resolutions[0].CacheHit || !resolutions[1].CacheHit || resolutions[1].Attempts != 0 {
+			t.Fatalf("%s Failed: unexpected cache hits [%+v] [%+v]", t.Name(), resolutions[0], resolutions[1])
+		} else if resolutions[1].IdentifierType != IdentifierHandCash || resolutions[1].StatusCode != http.StatusOK {
+			t.Fatalf("%s Failed: unexpected cache hit [%+v]", t.Name(), resolutions[1])
+		}
+	})
+
+	t.Run("paymail requests", func(t *testing.T) {
+		_, client := newMockPaymailServer(t, basicCapabilities, map[string]http.HandlerFunc{
+			"/api/v1/bsvalias/address/": addressHandler(t),
+		})
+		observer := new(mockObserver)
+		client.observer = observer
+		if _, err := client.ResolvePaymail(context.Background(), "mrz@handcash.io"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		requests, resolutions := observer.reset()
+		if len(requests) != 2 || len(resolutions) != 0 {
+			t.Fatalf("%s Failed: unexpected events [%d] [%d]", t.Name(), len(requests), len(resolutions))
+		} else if requests[0].Upstream != UpstreamPaymail || requests[1].Method != http.MethodPost || requests[1].StatusCode != http.StatusOK {
+			t.Fatalf("%s Failed: unexpected requests [%+v] [%+v]", t.Name(), requests[0], requests[1])
+		}
+	})
+}
+
+// TestPrometheusObserver will test the PrometheusObserver metrics
+func TestPrometheusObserver(t *testing.T) {
+	t.Parallel()
+
+	observer := NewPrometheusObserver(1, 0.1, 0.5)
+	observer.ObserveResolution(&ResolutionEvent{Attempts: 1, Duration: 50 * time.Millisecond, IdentifierType: IdentifierHandCash, Outcome: OutcomeSuccess})
+	observer.ObserveResolution(&ResolutionEvent{Attempts: 3, Duration: 2 * time.Second, IdentifierType: IdentifierHandCash, Outcome: OutcomeUpstreamUnavailable})
+	observer.ObserveResolution(&ResolutionEvent{CacheHit: true, Duration: time.Millisecond, IdentifierType: IdentifierType(`we"ird`), Outcome: OutcomeSuccess})
+	observer.ObserveRequest(&RequestEvent{Duration: 300 * time.Millisecond, Method: http.MethodGet, StatusCode: http.StatusOK, Upstream: UpstreamPolynym})
+	observer.ObserveRequest(&RequestEvent{Duration: time.Second, Err: errors.New("timeout"), Method: http.MethodPost, Upstream: UpstreamPaymail})
+
+	var buf bytes.Buffer
+	if err := observer.WriteMetrics(&buf); err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	}
+	output := buf.String()
+	for _, expected := range []string{
+		"# TYPE polynym_resolutions_total counter\n",
+		`polynym_resolutions_total{type="handcash",outcome="success"} 1` + "\n",
+		`polynym_resolutions_total{type="handcash",outcome="upstream_unavailable"} 1` + "\n",
+		`polynym_resolutions_total{type="we\"ird",outcome="success"} 1` + "\n",
+		`polynym_resolution_cache_hits_total{type="we\"ird"} 1` + "\n",
+		`polynym_resolution_attempts_total{type="handcash"} 4` + "\n",
+		"# TYPE polynym_resolution_duration_seconds histogram\n",
+		`polynym_resolution_duration_seconds_bucket{type="handcash",le="0.1"} 1` + "\n",
+		`polynym_resolution_duration_seconds_bucket{type="handcash",le="0.5"} 1` + "\n",
+		`polynym_resolution_duration_seconds_bucket{type="handcash",le="1"} 1` + "\n",
+		`polynym_resolution_duration_seconds_bucket{type="handcash",le="+Inf"} 2` + "\n",
+		`polynym_resolution_duration_seconds_sum{type="handcash"} 2.05` + "\n",
+		`polynym_resolution_duration_seconds_count{type="handcash"} 2` + "\n",
+		`polynym_http_requests_total{upstream="polynym",method="GET",code="200"} 1` + "\n",
+		`polynym_http_requests_total{upstream="paymail",method="POST",code="error"} 1` + "\n",
+		`polynym_http_request_duration_seconds_bucket{upstream="paymail",le="1"} 1` + "\n",
+		`polynym_http_request_duration_seconds_bucket{upstream="polynym",le="0.5"} 1` + "\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("%s Failed: expected [%s] in: [%s]", t.Name(), strings.TrimSpace(expected), output)
+		}
+	}
+
+	// Serve the metrics
+	recorder := httptest.NewRecorder()
+	observer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Header().Get("Content-Type") != prometheusContentType || recorder.Body.String() != output {
+		t.Fatalf("%s Failed: unexpected response [%s] [%s]", t.Name(), recorder.Header().Get("Content-Type"), recorder.Body.String())
+	}
+}
+
+// ExampleNewPrometheusObserver example using NewPrometheusObserver()
+func ExampleNewPrometheusObserver() {
+	observer := NewPrometheusObserver()
+	client := &Client{httpClient: &mockHTTP{}, observer: observer}
+	_, _ = client.GetAddress("16ZqP5Tb22KJuvSAbjNkoiZs13mmRmexZA")
+
+	var buf bytes.Buffer
+	_ = observer.WriteMetrics(&buf)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "polynym_resolutions_total") {
+			fmt.Println(line)
+		}
+	}
+	// Output:polynym_resolutions_total{type="address",outcome="success"} 1
+}
+
+// BenchmarkPrometheusObserver_ObserveResolution benchmarks the ObserveResolution method
+func BenchmarkPrometheusObserver_ObserveResolution(b *testing.B) {
+	observer := NewPrometheusObserver()
+	event := &ResolutionEvent{Attempts: 1, Duration: 50 * time.Millisecond, IdentifierType: IdentifierHandCash, Outcome: OutcomeSuccess}
+	for i := 0; i < b.N; i++ {
+		observer.ObserveResolution(event)
+	}
+}
@@ -2,12 +2,20 @@ package polynym
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
 )
 
 // mockHTTP for mocking requests
@@ -43,6 +51,18 @@ func (m *mockHTTP) Do(req *http.Request) (*http.Response, error) {
 		// Return a bad error response from Polynym (empty)
 		resp.Body = invalidResponse("Some error message", req.URL.String(), http.StatusUnavailableForLegalReasons)
 
+	} else if strings.Contains(req.URL.String(), "/rate-limited") {
+
+		// Return a rate limit response from Polynym
+		resp.StatusCode = http.StatusTooManyRequests
+		resp.Body = invalidResponse("too many requests", req.URL.String(), resp.StatusCode)
+
+	} else if strings.Contains(req.URL.String(), "/bad-poly-json") {
+
+		// Return a response that is not JSON
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBufferString("not-json"))
+
 	} else if strings.Contains(req.URL.String(), "/16ZqP5Tb22KJuvSAbjNkoiZs13mmRmexZA") {
 
 		// Valid BSV Address
@@ -86,7 +106,7 @@ func (m *mockHTTP) Do(req *http.Request) (*http.Response, error) {
 		// Valid paymail
 		resp.StatusCode = http.StatusOK
 		resp.Body = validResponse("19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", req.URL.String(), resp.StatusCode)
-	} else if strings.Contains(req.URL.String(), "/@833") {
+	} else if strings.Contains(req.URL.String(), "/833@twetch.me") {
 
 		// Valid Twetch ID
 		resp.StatusCode = http.StatusOK
@@ -125,3 +145,226 @@ func invalidResponse(errorMessage, url string, status int) io.ReadCloser {
 	b, _ := json.Marshal(result) // nolint: errchkjson // used in testing
 	return ioutil.NopCloser(bytes.NewBuffer(b))
 }
+
+// mockHTTPUnavailable for mocking an upstream that is always down
+type mockHTTPUnavailable struct {
+	attempts int
+}
+
+// Do is a mock http request (always returns a 503)
+func (m *mockHTTPUnavailable) Do(req *http.Request) (*http.Response, error) {
+	m.attempts++
+	return &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Body:       invalidResponse("service unavailable", req.URL.String(), http.StatusServiceUnavailable),
+	}, nil
+}
+
+// mockHTTPBlocking for mocking a request that never returns until the context is done
+type mockHTTPBlocking struct{}
+
+// Do is a mock http request (blocks until the request context is done)
+func (m *mockHTTPBlocking) Do(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+// mockHTTPCounter for counting the requests sent to the mock http client
+type mockHTTPCounter struct {
+	requests int64
+}
+
+// Do is a mock http request (counts and then uses mockHTTP)
+func (m *mockHTTPCounter) Do(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&m.requests, 1)
+	return (&mockHTTP{}).Do(req)
+}
+
+// count returns the number of requests sent
+func (m *mockHTTPCounter) count() int64 {
+	return atomic.LoadInt64(&m.requests)
+}
+
+// mockCacheError for mocking a cache that always fails
+type mockCacheError struct{}
+
+// Delete is a mock cache delete (always fails)
+func (m *mockCacheError) Delete(_ context.Context, _ string) error {
+	return fmt.Errorf("cache error")
+}
+
+// Get is a mock cache get (always fails, the error contains the key)
+func (m *mockCacheError) Get(_ context.Context, key string) ([]byte, bool, error) {
+	return nil, false, fmt.Errorf("cache error: %s", key)
+}
+
+// Set is a mock cache set (always fails, the error contains the key)
+func (m *mockCacheError) Set(_ context.Context, key string, _ []byte, _ time.Duration) error {
+	return fmt.Errorf("cache error: %s", key)
+}
+
+// mockHTTPConcurrent for tracking the max number of concurrent requests
+type mockHTTPConcurrent struct {
+	current int64
+	max     int64
+}
+
+// Do is a mock http request (waits a bit to overlap with other requests and then uses mockHTTP)
+func (m *mockHTTPConcurrent) Do(req *http.Request) (*http.Response, error) {
+	current := atomic.AddInt64(&m.current, 1)
+	defer atomic.AddInt64(&m.current, -1)
+	for {
+		previous := atomic.LoadInt64(&m.max)
+		if current <= previous || atomic.CompareAndSwapInt64(&m.max, previous, current) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return (&mockHTTP{}).Do(req)
+}
+
+// mockHTTPGate for holding requests until the gate is opened
+type mockHTTPGate struct {
+	canceled int64
+	open     chan struct{}
+	requests int64
+}
+
+// Do is a mock http request (waits for the gate to open, then uses mockHTTP)
+func (m *mockHTTPGate) Do(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&m.requests, 1)
+	select {
+	case <-m.open:
+		return (&mockHTTP{}).Do(req)
+	case <-req.Context().Done():
+		atomic.AddInt64(&m.canceled, 1)
+		return nil, req.Context().Err()
+	}
+}
+
+// mockDNS for mocking the SRV lookups (always returns the host and port)
+type mockDNS struct {
+	err     error
+	host    string
+	lookups int64
+	port    uint16
+}
+
+// LookupSRV is a mock SRV lookup
+func (m *mockDNS) LookupSRV(_ context.Context, _, _, _ string) (string, []*net.SRV, error) {
+	atomic.AddInt64(&m.lookups, 1)
+	if m.err != nil {
+		return "", nil, m.err
+	}
+	return "", []*net.SRV{{Target: m.host + ".", Port: m.port, Priority: 10, Weight: 10}}, nil
+}
+
+// mockHTTPRouter for sending Polynym requests to one mock and all other requests to another
+type mockHTTPRouter struct {
+	other   httpInterface
+	polynym httpInterface
+}
+
+// Do is a mock http request (routed by host)
+func (m *mockHTTPRouter) Do(req *http.Request) (*http.Response, error) {
+	if strings.HasPrefix(req.URL.String(), apiEndpoint) {
+		return m.polynym.Do(req)
+	}
+	return m.other.Do(req)
+}
+
+// newMockPaymailServer will start a TLS paymail provider and return a client that trusts it
+//
+// The capabilities are created from the base URL of the server, the routes are added to the server.
+// The SRV target (127.0.0.1) is not in the paymail domain, so the client trusts the SRV target.
+func newMockPaymailServer(t *testing.T, capabilities func(baseURL string) map[string]interface{},
+	routes map[string]http.HandlerFunc) (*httptest.Server, *Client) {
+
+	mux := http.NewServeMux()
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc(paymailWellKnownPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&Capabilities{BsvAlias: "1.0", Capabilities: capabilities(server.URL)})
+	})
+	for path, handler := range routes {
+		mux.HandleFunc(path, handler)
+	}
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return server, &Client{
+		dnsResolver:     &mockDNS{host: host, port: uint16(portNumber)},
+		httpClient:      server.Client(),
+		paymailSender:   "ops@example.com",
+		paymailTrustSRV: true,
+		UserAgent:       defaultUserAgent,
+	}
+}
+
+// writeJSON will write the value as JSON with the status code
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// mockObserver for recording the metrics events
+type mockObserver struct {
+	mu          sync.Mutex
+	requests    []*RequestEvent
+	resolutions []*ResolutionEvent
+}
+
+// ObserveRequest records the request event
+func (m *mockObserver) ObserveRequest(event *RequestEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, event)
+}
+
+// ObserveResolution records the resolution event
+func (m *mockObserver) ObserveResolution(event *ResolutionEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resolutions = append(m.resolutions, event)
+}
+
+// reset returns the recorded events and clears them
+func (m *mockObserver) reset() ([]*RequestEvent, []*ResolutionEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	requests, resolutions := m.requests, m.resolutions
+	m.requests, m.resolutions = nil, nil
+	return requests, resolutions
+}
+
+// mockLogEntry is a log entry recorded by the mockLogger
+type mockLogEntry struct {
+	fields  map[string]interface{}
+	level   LogLevel
+	message string
+}
+
+// mockLogger for recording the log entries
+type mockLogger struct {
+	entries []*mockLogEntry
+	mu      sync.Mutex
+}
+
+// Log records the entry
+func (m *mockLogger) Log(_ context.Context, level LogLevel, message string, fields map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, &mockLogEntry{fields: fields, level: level, message: message})
+}
+
+// reset returns the recorded entries and clears them
+func (m *mockLogger) reset() []*mockLogEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := m.entries
+	m.entries = nil
+	return entries
+}
@@ -0,0 +1,392 @@
+package polynym
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BRFC IDs of the known paymail capabilities
+const (
+	BRFCPki                   = "0c4339ef99c2" // BRFCPki is the public key infrastructure (alias: pki)
+	BRFCPaymentDestination    = "759684b1a19a" // BRFCPaymentDestination is the basic address resolution (alias: paymentDestination)
+	BRFCSenderValidation      = "6745385c3fc0" // BRFCSenderValidation is when the sender must sign the request
+	BRFCVerifyPublicKeyOwner  = "a9f510c16bde" // BRFCVerifyPublicKeyOwner is the public key owner verification
+	BRFCP2PPaymentDestination = "2a40af698840" // BRFCP2PPaymentDestination is the P2P payment destination
+	BRFCP2PReceiveTransaction = "5f1323cddf31" // BRFCP2PReceiveTransaction is the P2P receive transaction
+	BRFCPublicProfile         = "f12f968c92d6" // BRFCPublicProfile is the public profile (name and avatar)
+)
+
+const (
+
+	// paymailService is the SRV service name for paymail (_bsvalias._tcp.domain.tld)
+	paymailService = "bsvalias"
+
+	// paymailProtocol is the SRV protocol for paymail
+	paymailProtocol = "tcp"
+
+	// paymailDefaultPort is the port used when there is no SRV record
+	paymailDefaultPort = 443
+
+	// paymailWellKnownPath is the path of the capability discovery document
+	paymailWellKnownPath = "/.well-known/bsvalias"
+
+	// paymailSenderName is the sender name used when resolving an address
+	paymailSenderName = "go-polynym"
+
+	// paymailMaxResponseSize is the max size of a response from a paymail provider (1MB)
+	paymailMaxResponseSize = 1 << 20
+)
+
+// capabilityAliases are the named aliases that can be used in place of the BRFC ID
+var capabilityAliases = map[string]string{
+	BRFCPki:                "pki",
+	BRFCPaymentDestination: "paymentDestination",
+}
+
+// Capabilities is the paymail capability discovery document (.well-known/bsvalias)
+type Capabilities struct {
+	BsvAlias     string                 `json:"bsvalias"`     // BsvAlias is the version of the specification
+	Capabilities map[string]interface{} `json:"capabilities"` // Capabilities is the map of BRFC ID (or alias) to URL template (or flag)
+}
+
+// GetString returns the URL template of the capability by BRFC ID (or its alias)
+func (c *Capabilities) GetString(brfcID string) string {
+	if value, ok := c.get(brfcID).(string); ok {
+		return value
+	}
+	return ""
+}
+
+// GetBool returns the flag of the capability by BRFC ID (or its alias)
+func (c *Capabilities) GetBool(brfcID string) bool {
+	if value, ok := c.get(brfcID).(bool); ok {
+		return value
+	}
+	return false
+}
+
+// Has returns true if the capability is found by BRFC ID (or its alias)
+func (c *Capabilities) Has(brfcID string) bool {
+	return c.get(brfcID) != nil
+}
+
+// get returns the capability by BRFC ID (falling back to its alias)
+func (c *Capabilities) get(brfcID string) interface{} {
+	if value, ok := c.Capabilities[brfcID]; ok {
+		return value
+	}
+	if alias, ok := capabilityAliases[brfcID]; ok {
+		return c.Capabilities[alias]
+	}
+	return nil
+}
+
+// addressResolutionRequest is the body for the basic address resolution (paymentDestination)
+type addressResolutionRequest struct {
+	Amount       uint64 `json:"amount,omitempty"`
+	Dt           string `json:"dt"`
+	Purpose      string `json:"purpose,omitempty"`
+	SenderHandle string `json:"senderHandle"`
+	SenderName   string `json:"senderName"`
+	Signature    string `json:"signature,omitempty"`
+}
+
+// addressResolutionResponse is the response from the basic address resolution (paymentDestination)
+type addressResolutionResponse struct {
+	Output string `json:"output"`
+}
+
+// paymailErrorResponse is the error body returned by most paymail providers
+type paymailErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// ResolvePaymail will resolve a paymail (or $handle, 1handle) to an address directly with the paymail provider
+//
+// This does not use Polynym: the SRV record is looked up (falling back to the domain), the capabilities
+// are discovered via .well-known/bsvalias and the address resolution endpoint is called. The output
+// script is decoded into the address. BitcoinSV addresses are validated locally and returned as-is.
+//
+// The request is sent with the PaymailSenderHandle (required by the specification, without it the error is
+// ErrInvalidInput before any request) and is not signed: if the provider requires sender validation
+// (BRFCSenderValidation) the error is ErrCapabilityNotFound.
+func (c *Client) ResolvePaymail(ctx context.Context, handleOrPaymail string) (*GetAddressResponse, error) {
+	if idType, normalized, err := c.classify(handleOrPaymail); err == nil && idType == IdentifierAddress {
+		return &GetAddressResponse{
+			Address:     normalized,
+			LastRequest: &LastRequest{Method: http.MethodGet, StatusCode: http.StatusOK},
+		}, nil
+	}
+	paymail, err := c.paymailFromInput(handleOrPaymail)
+	if err != nil {
+		return nil, err
+	}
+	return c.resolvePaymail(ctx, paymail)
+}
+
+// resolvePaymail will resolve the (normalized) paymail to an address using the paymentDestination capability
+func (c *Client) resolvePaymail(ctx context.Context, paymail string) (*GetAddressResponse, error) {
+
+	// The sender handle is required for the address resolution
+	if len(c.paymailSender) == 0 {
+		return nil, errMissingSenderHandle()
+	}
+
+	// Discover the capabilities of the provider
+	capabilities, err := c.GetCapabilities(ctx, paymail)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request the output script
+	response := &GetAddressResponse{}
+	var script string
+	if script, response.LastRequest, err = c.resolveOutputScript(ctx, paymail, capabilities, 0); err != nil {
+		return response, err
+	}
+
+	// Decode the output script into the address
+	if response.Address, err = AddressFromScript(script); err != nil {
+		return response, withLastRequest(err, response.LastRequest)
+	}
+	return response, nil
+}
+
+// resolveOutputScript will request the output script for the (normalized) paymail using the paymentDestination capability
+func (c *Client) resolveOutputScript(ctx context.Context, paymail string, capabilities *Capabilities,
+	satoshis uint64) (string, *LastRequest, error) {
+
+	// Get the address resolution endpoint
+	endpoint := capabilities.GetString(BRFCPaymentDestination)
+	if len(endpoint) == 0 {
+		return "", nil, newResolveError(ErrCapabilityNotFound, nil, BRFCPaymentDestination, nil)
+	}
+
+	// Requests are not signed, so a provider that requires sender validation is not supported
+	if capabilities.GetBool(BRFCSenderValidation) {
+		return "", nil, newResolveError(ErrCapabilityNotFound, nil, BRFCSenderValidation+" (signed requests are not supported)", nil)
+	} else if len(c.paymailSender) == 0 {
+		return "", nil, errMissingSenderHandle()
+	}
+
+	// Request the output script
+	alias, domain := splitPaymail(paymail)
+	result := new(addressResolutionResponse)
+	lastRequest, err := c.paymailRequest(
+		ctx, http.MethodPost, expandTemplate(endpoint, alias, domain, ""), &addressResolutionRequest{
+			Amount:       satoshis,
+			Dt:           time.Now().UTC().Format(time.RFC3339),
+			SenderHandle: c.paymailSender,
+			SenderName:   paymailSenderName,
+		}, result,
+	)
+	if err != nil {
+		return "", lastRequest, err
+	} else if len(result.Output) == 0 {
+		return "", lastRequest, newResolveError(ErrDecodeFailure, lastRequest, "missing output in response", nil)
+	}
+	return result.Output, lastRequest, nil
+}
+
+// errMissingSenderHandle is the error when the address resolution is used without a PaymailSenderHandle
+func errMissingSenderHandle() error {
+	return newResolveError(ErrInvalidInput, nil, "missing sender handle (PaymailSenderHandle is required to resolve paymails)", nil)
+}
+
+// paymailFromInput will convert the input ($handle, 1handle or paymail) into a normalized paymail
+func (c *Client) paymailFromInput(input string) (string, error) {
+	idType, normalized, err := c.classify(input)
+	if err != nil {
+		return "", err
+	}
+	if idType == IdentifierPaymail || c.handleRegistry().isHandleType(idType) {
+		return normalized, nil
+	}
+	return "", newResolveError(ErrInvalidInput, nil, "not a paymail: "+input, nil)
+}
+
+// paymailCapabilities will convert the input into a paymail and discover the capabilities of the provider
+func (c *Client) paymailCapabilities(ctx context.Context, handleOrPaymail string) (string, *Capabilities, error) {
+	paymail, err := c.paymailFromInput(handleOrPaymail)
+	if err != nil {
+		return "", nil, err
+	}
+	var capabilities *Capabilities
+	if capabilities, err = c.GetCapabilities(ctx, paymail); err != nil {
+		return "", nil, err
+	}
+	return paymail, capabilities, nil
+}
+
+// GetCapabilities will discover the paymail capabilities for the domain (or paymail)
+//
+// The SRV record (_bsvalias._tcp.domain.tld) is used to find the host, falling back to the domain
+// (also when the SRV target is outside of the domain, see PaymailTrustSRVTarget)
+func (c *Client) GetCapabilities(ctx context.Context, domain string) (*Capabilities, error) {
+	if strings.Contains(domain, "@") {
+		_, domain = splitPaymail(domain)
+	}
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if !isValidDomain(domain) {
+		return nil, newResolveError(ErrInvalidInput, nil, "invalid domain: "+domain, nil)
+	}
+
+	host, _, _ := c.lookupPaymailHost(ctx, domain)
+	capabilities := new(Capabilities)
+	if _, err := c.paymailRequest(ctx, http.MethodGet, "https://"+host+paymailWellKnownPath, nil, capabilities); err != nil {
+		return nil, err
+	}
+	return capabilities, nil
+}
+
+// lookupPaymailHost returns the host (and port) of the paymail service for the domain
+//
+// If the SRV record is not found (or the lookup fails) the domain is used, the SRV record is nil.
+// The specification requires DNSSEC for an SRV target outside of the domain, so unless PaymailTrustSRVTarget
+// is set, such a target is rejected: the domain is used and the record is returned with the error.
+func (c *Client) lookupPaymailHost(ctx context.Context, domain string) (string, *net.SRV, error) {
+	resolver := c.dnsResolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	_, records, err := resolver.LookupSRV(ctx, paymailService, paymailProtocol, domain)
+	if err != nil || len(records) == 0 {
+		return domain, nil, err
+	}
+	srv := records[0]
+	target := strings.ToLower(strings.TrimSuffix(srv.Target, "."))
+	if !c.paymailTrustSRV && !isValidSRVTarget(target, domain) {
+		return domain, srv, fmt.Errorf("SRV target %s is not %s or a subdomain of it (requires DNSSEC)", target, domain)
+	}
+	if srv.Port == paymailDefaultPort {
+		return target, srv, nil
+	}
+	return net.JoinHostPort(target, strconv.Itoa(int(srv.Port))), srv, nil
+}
+
+// isValidSRVTarget returns true if the SRV target is the domain or a subdomain of it
+func isValidSRVTarget(target, domain string) bool {
+	return target == domain || strings.HasSuffix(target, "."+domain)
+}
+
+// paymailRequest will fire a JSON request to a paymail provider and decode the result
+func (c *Client) paymailRequest(ctx context.Context, method, reqURL string, payload, result interface{}) (*LastRequest, error) {
+	return c.paymailRequestWithRetries(ctx, method, reqURL, payload, result, c.retryCount)
+}
+
+// paymailRequestWithRetries will fire a JSON request to a paymail provider (retried up to the retry count)
+// and decode the result, requests that are not idempotent should not be retried (retry count of 0)
+func (c *Client) paymailRequestWithRetries(ctx context.Context, method, reqURL string, payload, result interface{},
+	retryCount int) (*LastRequest, error) {
+	lastRequest := &LastRequest{Method: method, URL: reqURL}
+
+	// Encode the payload (if any)
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return lastRequest, newResolveError(ErrInvalidInput, lastRequest, "", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	// Start the request
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return lastRequest, newResolveError(ErrInvalidInput, lastRequest, "", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	// Fire the request
+	var resp *http.Response
+	if resp, err = c.doRequest(req, lastRequest, retryCount); err != nil {
+		if resp != nil {
+			lastRequest.StatusCode = resp.StatusCode
+		}
+		return lastRequest, newResolveError(ErrTransportFailure, lastRequest, "", err)
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	lastRequest.StatusCode = resp.StatusCode
+
+	// Read the body (limited in size)
+	var data []byte
+	if resp.Body != nil {
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, paymailMaxResponseSize)); err != nil {
+			return lastRequest, newResolveError(ErrTransportFailure, lastRequest, "", err)
+		}
+	}
+
+	// Handle errors
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return lastRequest, paymailStatusError(lastRequest, data)
+	}
+
+	// Decode the result
+	if result != nil {
+		if err = json.Unmarshal(data, result); err != nil {
+			err = newResolveError(ErrDecodeFailure, lastRequest, "", err)
+			c.logDecodeFailure(ctx, lastRequest, err)
+			return lastRequest, err
+		}
+	}
+	return lastRequest, nil
+}
+
+// paymailStatusError will create the error for a non-2xx response from a paymail provider
+//
+// Other client errors (4xx) are ErrInvalidInput: the provider rejected the request, it is not unavailable
+func paymailStatusError(lastRequest *LastRequest, data []byte) error {
+	kind := ErrUpstreamUnavailable
+	switch code := lastRequest.StatusCode; {
+	case code == http.StatusNotFound:
+		kind = ErrNotFound
+	case code == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	case code >= http.StatusBadRequest && code < http.StatusInternalServerError:
+		kind = ErrInvalidInput
+	}
+
+	// Try to get the message from the body (not required)
+	errResponse := new(paymailErrorResponse)
+	_ = json.Unmarshal(data, errResponse)
+	message := errResponse.Message
+	if len(message) == 0 {
+		message = errResponse.Error
+	}
+	if len(message) == 0 {
+		message = fmt.Sprintf("bad response from paymail provider: %d", lastRequest.StatusCode)
+	}
+	return newResolveError(kind, lastRequest, message, nil)
+}
+
+// expandTemplate will replace the placeholders in a capability URL template
+func expandTemplate(template, alias, domain, pubKey string) string {
+	return strings.NewReplacer(
+		"{alias}", alias,
+		"{domain.tld}", domain,
+		"{pubkey}", pubKey,
+	).Replace(template)
+}
+
+// splitPaymail will split a paymail into the alias and domain
+func splitPaymail(paymail string) (alias, domain string) {
+	if index := strings.LastIndex(paymail, "@"); index >= 0 {
+		return paymail[:index], paymail[index+1:]
+	}
+	return "", paymail
+}
@@ -0,0 +1,267 @@
+package polynym
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// brfcNames are the names of the known BRFC IDs
+var brfcNames = map[string]string{
+	BRFCP2PPaymentDestination: "P2P Payment Destination",
+	BRFCP2PReceiveTransaction: "P2P Receive Transaction",
+	BRFCPaymentDestination:    "Payment Destination",
+	BRFCPki:                   "Public Key Infrastructure",
+	BRFCPublicProfile:         "Public Profile",
+	BRFCSenderValidation:      "Sender Validation",
+	BRFCVerifyPublicKeyOwner:  "Verify Public Key Owner",
+}
+
+// PaymailInspection is the report from InspectPaymail() for diagnosing a paymail provider
+type PaymailInspection struct {
+	Capabilities []*CapabilityInfo `json:"capabilities"`           // Capabilities are decoded into known BRFC IDs
+	Document     *Capabilities     `json:"document,omitempty"`     // Document is the .well-known/bsvalias document
+	Domain       string            `json:"domain"`                 // Domain is the paymail domain
+	Host         string            `json:"host"`                   // Host is the host (and port) used for the capability discovery
+	LastRequest  *LastRequest      `json:"last_request,omitempty"` // LastRequest is the request for the capability discovery
+	SRV          *SRVRecord        `json:"srv,omitempty"`          // SRV is the SRV record (nil if not found)
+	SRVError     string            `json:"srv_error,omitempty"`    // SRVError is the error from the SRV lookup (if any)
+	TLS          *TLSInfo          `json:"tls,omitempty"`          // TLS is the connection details of the capability discovery
+	Violations   []string          `json:"violations"`             // Violations are the problems found with the provider
+}
+
+// CapabilityInfo is a capability from the .well-known/bsvalias document
+type CapabilityInfo struct {
+	BRFCID string      `json:"brfc_id"` // BRFCID is the BRFC ID (resolved from the alias if used)
+	Key    string      `json:"key"`     // Key is the key used in the document (BRFC ID or alias)
+	Known  bool        `json:"known"`   // Known is true if the BRFC ID is known by this library
+	Name   string      `json:"name"`    // Name is the name of the capability (if known)
+	Value  interface{} `json:"value"`   // Value is the URL template or flag
+}
+
+// SRVRecord is the SRV record of the paymail domain (_bsvalias._tcp.domain.tld)
+type SRVRecord struct {
+	Port     uint16 `json:"port"`
+	Priority uint16 `json:"priority"`
+	Target   string `json:"target"`
+	Weight   uint16 `json:"weight"`
+}
+
+// TLSInfo is the TLS connection details of a request
+type TLSInfo struct {
+	CipherSuite  string             `json:"cipher_suite"`
+	Certificates []*CertificateInfo `json:"certificates"`
+	ServerName   string             `json:"server_name"`
+	Version      string             `json:"version"`
+}
+
+// CertificateInfo is a certificate presented by the server
+type CertificateInfo struct {
+	DNSNames  []string  `json:"dns_names"`
+	Issuer    string    `json:"issuer"`
+	NotAfter  time.Time `json:"not_after"`
+	NotBefore time.Time `json:"not_before"`
+	Subject   string    `json:"subject"`
+}
+
+// InspectPaymail will inspect the paymail provider for a paymail (or domain) for diagnosing misconfiguration
+//
+// The report contains the SRV record, the .well-known/bsvalias document, the capabilities decoded into
+// known BRFC IDs, the TLS details and any violations of the specification. The report is returned
+// (as complete as possible) even when an error occurs, the error is also listed as a violation.
+func (c *Client) InspectPaymail(ctx context.Context, paymailOrDomain string) (*PaymailInspection, error) {
+	domain := strings.ToLower(strings.TrimSpace(paymailOrDomain))
+	if strings.Contains(domain, "@") {
+		_, domain = splitPaymail(domain)
+	}
+	inspection := &PaymailInspection{Domain: domain, Violations: []string{}}
+	if !isValidDomain(domain) {
+		return inspection, newResolveError(ErrInvalidInput, nil, "invalid domain: "+domain, nil)
+	}
+
+	// Look up the SRV record
+	host, record, err := c.lookupPaymailHost(ctx, domain)
+	inspection.Host = host
+	if err != nil {
+		inspection.SRVError = err.Error()
+	}
+	if record != nil {
+		inspection.SRV = &SRVRecord{
+			Port:     record.Port,
+			Priority: record.Priority,
+			Target:   strings.TrimSuffix(record.Target, "."),
+			Weight:   record.Weight,
+		}
+		if target := strings.ToLower(inspection.SRV.Target); !isValidSRVTarget(target, domain) {
+			inspection.violation("SRV target %s is not %s or a subdomain of it (requires DNSSEC)", target, domain)
+		}
+	}
+
+	// Get the capability discovery document
+	var resp *http.Response
+	var body []byte
+	if resp, body, err = c.inspectRequest(ctx, "https://"+host+paymailWellKnownPath, inspection); err != nil {
+		inspection.violation("capability discovery failed: %s", err.Error())
+		return inspection, err
+	}
+	inspection.TLS = newTLSInfo(resp.TLS)
+
+	// Check the response
+	if resp.StatusCode != http.StatusOK {
+		err = paymailStatusError(inspection.LastRequest, body)
+		inspection.violation("capability discovery returned status %d (expected 200)", resp.StatusCode)
+		return inspection, err
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		inspection.violation("capability discovery content type is %q (expected application/json)", resp.Header.Get("Content-Type"))
+	}
+
+	// Decode the document
+	inspection.Document = new(Capabilities)
+	if err = json.Unmarshal(body, inspection.Document); err != nil {
+		inspection.Document = nil
+		inspection.violation("capability discovery is not valid JSON: %s", err.Error())
+		return inspection, newResolveError(ErrDecodeFailure, inspection.LastRequest, "", err)
+	}
+	inspection.inspectDocument()
+	return inspection, nil
+}
+
+// inspectRequest will fire a GET request and return the response with the body (limited in size)
+func (c *Client) inspectRequest(ctx context.Context, reqURL string, inspection *PaymailInspection) (*http.Response, []byte, error) {
+	inspection.LastRequest = &LastRequest{Method: http.MethodGet, URL: reqURL}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, nil, newResolveError(ErrInvalidInput, inspection.LastRequest, "", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	var resp *http.Response
+	if resp, err = c.doRequest(req, inspection.LastRequest, c.retryCount); err != nil {
+		return nil, nil, newResolveError(ErrTransportFailure, inspection.LastRequest, "", err)
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	inspection.LastRequest.StatusCode = resp.StatusCode
+
+	var body []byte
+	if resp.Body != nil {
+		if body, err = io.ReadAll(io.LimitReader(resp.Body, paymailMaxResponseSize)); err != nil {
+			return nil, nil, newResolveError(ErrTransportFailure, inspection.LastRequest, "", err)
+		}
+	}
+	return resp, body, nil
+}
+
+// inspectDocument will decode the capabilities and check the document against the specification
+func (p *PaymailInspection) inspectDocument() {
+	if len(p.Document.BsvAlias) == 0 {
+		p.violation("missing bsvalias version")
+	}
+	if len(p.Document.Capabilities) == 0 {
+		p.violation("missing capabilities")
+	}
+
+	// Decode the capabilities (sorted by key for a stable report)
+	keys := make([]string, 0, len(p.Document.Capabilities))
+	for key := range p.Document.Capabilities {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	p.Capabilities = make([]*CapabilityInfo, 0, len(keys))
+	for _, key := range keys {
+		info := &CapabilityInfo{BRFCID: key, Key: key, Value: p.Document.Capabilities[key]}
+		for brfcID, alias := range capabilityAliases {
+			if alias == key {
+				info.BRFCID = brfcID
+			}
+		}
+		info.Name, info.Known = brfcNames[info.BRFCID]
+		p.Capabilities = append(p.Capabilities, info)
+
+		// URL templates must be https and use the placeholders
+		if template, ok := info.Value.(string); ok {
+			p.inspectTemplate(key, template)
+		}
+	}
+
+	// Required capabilities
+	if !p.Document.Has(BRFCPki) {
+		p.violation("missing required capability pki (%s)", BRFCPki)
+	}
+	if !p.Document.Has(BRFCPaymentDestination) {
+		p.violation("missing required capability paymentDestination (%s)", BRFCPaymentDestination)
+	}
+}
+
+// inspectTemplate will check a capability URL template
+func (p *PaymailInspection) inspectTemplate(key, template string) {
+	parsed, err := url.Parse(strings.NewReplacer("{alias}", "alias", "{domain.tld}", "domain.tld", "{pubkey}", "pubkey").Replace(template))
+	if err != nil || len(parsed.Host) == 0 {
+		p.violation("capability %s is not a valid URL: %s", key, template)
+		return
+	}
+	if parsed.Scheme != "https" {
+		p.violation("capability %s does not use https: %s", key, template)
+	}
+	if !strings.Contains(template, "{alias}") || !strings.Contains(template, "{domain.tld}") {
+		p.violation("capability %s is missing the {alias} or {domain.tld} placeholder: %s", key, template)
+	}
+	if key == BRFCVerifyPublicKeyOwner && !strings.Contains(template, "{pubkey}") {
+		p.violation("capability %s is missing the {pubkey} placeholder: %s", key, template)
+	}
+}
+
+// violation will add a violation to the report
+func (p *PaymailInspection) violation(format string, args ...interface{}) {
+	p.Violations = append(p.Violations, fmt.Sprintf(format, args...))
+}
+
+// newTLSInfo returns the TLS details from the connection state (nil if not TLS)
+func newTLSInfo(state *tls.ConnectionState) *TLSInfo {
+	if state == nil {
+		return nil
+	}
+	info := &TLSInfo{
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+		Version:     tlsVersionName(state.Version),
+	}
+	for _, cert := range state.PeerCertificates {
+		info.Certificates = append(info.Certificates, &CertificateInfo{
+			DNSNames:  cert.DNSNames,
+			Issuer:    cert.Issuer.String(),
+			NotAfter:  cert.NotAfter,
+			NotBefore: cert.NotBefore,
+			Subject:   cert.Subject.String(),
+		})
+	}
+	return info
+}
+
+// tlsVersionName returns the name of the TLS version
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
@@ -0,0 +1,147 @@
+package polynym
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hasViolation returns true if a violation contains the text
+func hasViolation(inspection *PaymailInspection, text string) bool {
+	for _, violation := range inspection.Violations {
+		if strings.Contains(violation, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestClient_InspectPaymail will test the InspectPaymail() method
+func TestClient_InspectPaymail(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid provider", func(t *testing.T) {
+		server, client := newMockPaymailServer(t, func(baseURL string) map[string]interface{} {
+			capabilities := pkiCapabilities(baseURL)
+			capabilities["paymentDestination"] = baseURL + "/api/v1/bsvalias/address/{alias}@{domain.tld}"
+			capabilities["abcdef123456"] = baseURL + "/api/v1/custom/{alias}@{domain.tld}"
+			return capabilities
+		}, nil)
+
+		inspection, err := client.InspectPaymail(context.Background(), "MrZ@HandCash.io")
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if inspection.Domain != "handcash.io" {
+			t.Fatalf("%s Failed: expected domain [%s] received: [%s]", t.Name(), "handcash.io", inspection.Domain)
+		} else if inspection.SRV == nil || inspection.SRV.Target != "127.0.0.1" || inspection.Host != strings.TrimPrefix(server.URL, "https://") {
+			t.Fatalf("%s Failed: unexpected SRV record [%v] and host [%s]", t.Name(), inspection.SRV, inspection.Host)
+		} else if inspection.TLS == nil || len(inspection.TLS.Certificates) == 0 || len(inspection.TLS.Version) == 0 {
+			t.Fatalf("%s Failed: expected the TLS details, received: [%v]", t.Name(), inspection.TLS)
+		} else if inspection.Document == nil || inspection.Document.BsvAlias != "1.0" {
+			t.Fatalf("%s Failed: expected the document, received: [%v]", t.Name(), inspection.Document)
+		} else if len(inspection.Capabilities) != 4 {
+			t.Fatalf("%s Failed: expected [%d] capabilities, received: [%d]", t.Name(), 4, len(inspection.Capabilities))
+		}
+
+		// Capabilities are sorted by key and aliases are resolved to BRFC IDs
+		expected := []struct {
+			key    string
+			brfcID string
+			known  bool
+		}{
+			{BRFCVerifyPublicKeyOwner, BRFCVerifyPublicKeyOwner, true},
+			{"abcdef123456", "abcdef123456", false},
+			{"paymentDestination", BRFCPaymentDestination, true},
+			{"pki", BRFCPki, true},
+		}
+		for i, capability := range inspection.Capabilities {
+			if capability.Key != expected[i].key || capability.BRFCID != expected[i].brfcID || capability.Known != expected[i].known {
+				t.Errorf("%s Failed: unexpected capability [%v]", t.Name(), capability)
+			}
+		}
+
+		// The SRV target is not a subdomain (the test server is on 127.0.0.1)
+		if len(inspection.Violations) != 1 || !hasViolation(inspection, "SRV target 127.0.0.1") {
+			t.Fatalf("%s Failed: unexpected violations %v", t.Name(), inspection.Violations)
+		}
+	})
+
+	t.Run("discovery failure", func(t *testing.T) {
+		client := newMockClient(defaultUserAgent)
+		client.dnsResolver = &mockDNS{err: errors.New("no such host")}
+		client.httpClient = &mockHTTPBlocking{}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		// Without an SRV record the domain is used
+		inspection, err := client.InspectPaymail(ctx, "mrz@handcash.io")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), context.DeadlineExceeded, err)
+		} else if inspection.SRVError != "no such host" || inspection.SRV != nil || inspection.Host != "handcash.io" {
+			t.Fatalf("%s Failed: expected the SRV error, received: [%s]", t.Name(), inspection.SRVError)
+		} else if !hasViolation(inspection, "capability discovery failed") {
+			t.Fatalf("%s Failed: unexpected violations %v", t.Name(), inspection.Violations)
+		}
+	})
+
+	t.Run("document violations", func(t *testing.T) {
+		_, client := newMockPaymailServer(t, func(baseURL string) map[string]interface{} {
+			return map[string]interface{}{
+				"paymentDestination":     strings.Replace(baseURL, "https://", "http://", 1) + "/address/{alias}@{domain.tld}",
+				BRFCVerifyPublicKeyOwner: baseURL + "/verify/{alias}@{domain.tld}",
+				BRFCPublicProfile:        baseURL + "/profile/{alias}",
+			}
+		}, nil)
+
+		inspection, err := client.InspectPaymail(context.Background(), "127.0.0.1.example.com")
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+		for _, violation := range []string{
+			"capability paymentDestination does not use https",
+			"capability " + BRFCVerifyPublicKeyOwner + " is missing the {pubkey} placeholder",
+			"capability " + BRFCPublicProfile + " is missing the {alias} or {domain.tld} placeholder",
+			"missing required capability pki",
+		} {
+			if !hasViolation(inspection, violation) {
+				t.Errorf("%s Failed: expected violation [%s] in %v", t.Name(), violation, inspection.Violations)
+			}
+		}
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		server, client := newMockPaymailServer(t, basicCapabilities, nil)
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		})
+		inspection, err := client.InspectPaymail(context.Background(), "handcash.io")
+		if !errors.Is(err, ErrDecodeFailure) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrDecodeFailure, err)
+		} else if !hasViolation(inspection, "content type is \"text/html\"") || !hasViolation(inspection, "not valid JSON") {
+			t.Fatalf("%s Failed: unexpected violations %v", t.Name(), inspection.Violations)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		server, client := newMockPaymailServer(t, basicCapabilities, nil)
+		server.Config.Handler = http.NotFoundHandler()
+		inspection, err := client.InspectPaymail(context.Background(), "handcash.io")
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrNotFound, err)
+		} else if !hasViolation(inspection, "returned status 404") {
+			t.Fatalf("%s Failed: unexpected violations %v", t.Name(), inspection.Violations)
+		}
+	})
+
+	t.Run("invalid domain", func(t *testing.T) {
+		client := newMockClient(defaultUserAgent)
+		if _, err := client.InspectPaymail(context.Background(), "localhost"); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrInvalidInput, err)
+		}
+	})
+}
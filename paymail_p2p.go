@@ -0,0 +1,157 @@
+package polynym
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+// PaymentOutput is an output to pay from a payment destination
+type PaymentOutput struct {
+	Address  string `json:"address,omitempty"` // Address is the decoded address of the script (if P2PKH or P2SH)
+	Satoshis uint64 `json:"satoshis"`          // Satoshis is the amount to pay to the output
+	Script   string `json:"script"`            // Script is the locking script (hex)
+}
+
+// PaymentDestination is the response from the P2P payment destination (or the basic address resolution)
+type PaymentDestination struct {
+	LastRequest *LastRequest     `json:"-"`         // LastRequest is the request to the paymail provider
+	Outputs     []*PaymentOutput `json:"outputs"`   // Outputs are the outputs to pay
+	P2P         bool             `json:"-"`         // P2P is false if the basic address resolution was used
+	Reference   string           `json:"reference"` // Reference is used when submitting the transaction (P2P only)
+}
+
+// p2pDestinationRequest is the body for the P2P payment destination
+type p2pDestinationRequest struct {
+	Satoshis uint64 `json:"satoshis"`
+}
+
+// GetPaymentDestination will request the P2P payment destination for a paymail (or $handle, 1handle) and amount
+//
+// The outputs (script and satoshis) and the reference are returned. If the provider does not support
+// the P2P payment destination capability, the basic address resolution is used (one output, no reference).
+func (c *Client) GetPaymentDestination(ctx context.Context, handleOrPaymail string, satoshis uint64) (*PaymentDestination, error) {
+	if satoshis == 0 {
+		return nil, newResolveError(ErrInvalidInput, nil, "satoshis must be greater than zero", nil)
+	}
+	paymail, capabilities, err := c.paymailCapabilities(ctx, handleOrPaymail)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fall back to the basic address resolution
+	endpoint := capabilities.GetString(BRFCP2PPaymentDestination)
+	if len(endpoint) == 0 {
+		destination := &PaymentDestination{}
+		var script string
+		if script, destination.LastRequest, err = c.resolveOutputScript(ctx, paymail, capabilities, satoshis); err != nil {
+			return destination, err
+		}
+		destination.Outputs = []*PaymentOutput{{Satoshis: satoshis, Script: script}}
+		destination.setAddresses()
+		return destination, nil
+	}
+
+	// Request the P2P payment destination
+	alias, domain := splitPaymail(paymail)
+	destination := &PaymentDestination{P2P: true}
+	if destination.LastRequest, err = c.paymailRequest(
+		ctx, http.MethodPost, expandTemplate(endpoint, alias, domain, ""),
+		&p2pDestinationRequest{Satoshis: satoshis}, destination,
+	); err != nil {
+		return destination, err
+	}
+
+	// Validate the outputs
+	if len(destination.Outputs) == 0 {
+		return destination, newResolveError(ErrDecodeFailure, destination.LastRequest, "missing outputs in response", nil)
+	}
+	for _, output := range destination.Outputs {
+		if output == nil || len(output.Script) == 0 {
+			return destination, newResolveError(ErrDecodeFailure, destination.LastRequest, "missing script in output", nil)
+		}
+	}
+	destination.setAddresses()
+	return destination, nil
+}
+
+// setAddresses will decode the address of each output (if the script is P2PKH or P2SH)
+func (p *PaymentDestination) setAddresses() {
+	for _, output := range p.Outputs {
+		output.Address, _ = AddressFromScript(output.Script)
+	}
+}
+
+// P2PTransaction is the transaction to submit to the receiver (P2P receive transaction)
+type P2PTransaction struct {
+	Hex       string         `json:"hex"`       // Hex is the raw transaction (hex)
+	MetaData  *P2PTxMetaData `json:"metadata"`  // MetaData is the (optional) information about the sender
+	Reference string         `json:"reference"` // Reference is from the P2P payment destination
+}
+
+// P2PTxMetaData is the (optional) information about the sender of a P2P transaction
+type P2PTxMetaData struct {
+	Note      string `json:"note,omitempty"`      // Note is a human-readable note for the receiver
+	PubKey    string `json:"pubkey,omitempty"`    // PubKey is the public key of the sender (required with the signature)
+	Sender    string `json:"sender,omitempty"`    // Sender is the paymail of the sender
+	Signature string `json:"signature,omitempty"` // Signature is the signature of the txid by the sender
+}
+
+// P2PTransactionResponse is the response from the receiver after submitting a P2P transaction
+type P2PTransactionResponse struct {
+	LastRequest *LastRequest `json:"-"`    // LastRequest is the request to the paymail provider
+	Note        string       `json:"note"` // Note is a human-readable note from the receiver
+	TxID        string       `json:"txid"` // TxID is the id of the accepted transaction
+}
+
+// SendP2PTransaction will submit the transaction to the receive-transaction capability of the paymail (or $handle, 1handle)
+//
+// The reference is from GetPaymentDestination(). If the receiver rejects the transaction (4xx)
+// the error will match ErrTransactionRejected and include the message from the receiver.
+//
+// The transaction is submitted once (never retried): after a transport error or a 5xx response the
+// receiver may have accepted it, so the caller decides whether to submit it again.
+func (c *Client) SendP2PTransaction(ctx context.Context, handleOrPaymail string,
+	transaction *P2PTransaction) (*P2PTransactionResponse, error) {
+
+	// Validate the transaction
+	if transaction == nil || len(transaction.Hex) == 0 {
+		return nil, newResolveError(ErrInvalidInput, nil, "missing transaction hex", nil)
+	} else if _, err := hex.DecodeString(transaction.Hex); err != nil {
+		return nil, newResolveError(ErrInvalidInput, nil, "invalid transaction hex", err)
+	} else if len(transaction.Reference) == 0 {
+		return nil, newResolveError(ErrInvalidInput, nil, "missing reference", nil)
+	}
+	paymail, capabilities, err := c.paymailCapabilities(ctx, handleOrPaymail)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := capabilities.GetString(BRFCP2PReceiveTransaction)
+	if len(endpoint) == 0 {
+		return nil, newResolveError(ErrCapabilityNotFound, nil, BRFCP2PReceiveTransaction, nil)
+	}
+
+	// Submit the transaction (once, it is not idempotent)
+	alias, domain := splitPaymail(paymail)
+	response := &P2PTransactionResponse{}
+	if response.LastRequest, err = c.paymailRequestWithRetries(
+		ctx, http.MethodPost, expandTemplate(endpoint, alias, domain, ""), transaction, response, 0,
+	); err != nil {
+		return response, rejectedError(err)
+	} else if len(response.TxID) == 0 {
+		return response, newResolveError(ErrDecodeFailure, response.LastRequest, "missing txid in response", nil)
+	}
+	return response, nil
+}
+
+// rejectedError will convert a client error (4xx other than not found or rate limited) into a rejection
+func rejectedError(err error) error {
+	var resolveErr *ResolveError
+	if errors.As(err, &resolveErr) && resolveErr.StatusCode >= http.StatusBadRequest &&
+		resolveErr.StatusCode < http.StatusInternalServerError &&
+		resolveErr.StatusCode != http.StatusNotFound && resolveErr.StatusCode != http.StatusTooManyRequests {
+		resolveErr.Kind = ErrTransactionRejected
+	}
+	return err
+}
@@ -0,0 +1,205 @@
+package polynym
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gojektech/heimdall/v6"
+)
+
+// p2pCapabilities returns the capabilities with the address resolution and P2P payment destination endpoints
+func p2pCapabilities(baseURL string) map[string]interface{} {
+	capabilities := basicCapabilities(baseURL)
+	capabilities[BRFCP2PPaymentDestination] = baseURL + "/api/v1/bsvalias/p2p-payment-destination/{alias}@{domain.tld}"
+	return capabilities
+}
+
+// p2pDestinationHandler returns two outputs splitting the satoshis
+func p2pDestinationHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body := new(p2pDestinationRequest)
+		if err := json.NewDecoder(req.Body).Decode(body); err != nil || body.Satoshis == 0 {
+			t.Errorf("invalid P2P destination request: %v", body)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"outputs": []map[string]interface{}{
+				{"script": testScript, "satoshis": body.Satoshis / 2},
+				{"script": "006a0568656c6c6f", "satoshis": body.Satoshis - body.Satoshis/2},
+			},
+			"reference": "z0bac4ec-6f15-42de-9ef4-e60bfdabf4f7",
+		})
+	}
+}
+
+// TestClient_GetPaymentDestination will test the GetPaymentDestination() method
+func TestClient_GetPaymentDestination(t *testing.T) {
+	t.Parallel()
+
+	t.Run("p2p payment destination", func(t *testing.T) {
+		_, client := newMockPaymailServer(t, p2pCapabilities, map[string]http.HandlerFunc{
+			"/api/v1/bsvalias/p2p-payment-destination/": p2pDestinationHandler(t),
+		})
+		destination, err := client.GetPaymentDestination(context.Background(), "$mrz", 1001)
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if !destination.P2P || destination.Reference != "z0bac4ec-6f15-42de-9ef4-e60bfdabf4f7" {
+			t.Fatalf("%s Failed: expected a P2P destination with a reference, received: [%v]", t.Name(), destination)
+		} else if len(destination.Outputs) != 2 {
+			t.Fatalf("%s Failed: expected [%d] outputs, received: [%d]", t.Name(), 2, len(destination.Outputs))
+		} else if destination.Outputs[0].Address != testAddress || destination.Outputs[0].Satoshis != 500 {
+			t.Fatalf("%s Failed: unexpected first output [%v]", t.Name(), destination.Outputs[0])
+		} else if len(destination.Outputs[1].Address) > 0 || destination.Outputs[1].Satoshis != 501 {
+			t.Fatalf("%s Failed: unexpected second output [%v]", t.Name(), destination.Outputs[1])
+		}
+	})
+
+	t.Run("falls back to basic address resolution", func(t *testing.T) {
+		_, client := newMockPaymailServer(t, basicCapabilities, map[string]http.HandlerFunc{
+			"/api/v1/bsvalias/address/": addressHandler(t),
+		})
+		destination, err := client.GetPaymentDestination(context.Background(), "mrz@handcash.io", 1000)
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if destination.P2P || len(destination.Reference) > 0 {
+			t.Fatalf("%s Failed: expected a basic destination, received: [%v]", t.Name(), destination)
+		} else if len(destination.Outputs) != 1 || destination.Outputs[0].Script != testScript ||
+			destination.Outputs[0].Satoshis != 1000 || destination.Outputs[0].Address != testAddress {
+			t.Fatalf("%s Failed: unexpected outputs [%v]", t.Name(), destination.Outputs)
+		}
+	})
+
+	t.Run("missing outputs", func(t *testing.T) {
+		_, client := newMockPaymailServer(t, p2pCapabilities, map[string]http.HandlerFunc{
+			"/api/v1/bsvalias/p2p-payment-destination/": func(w http.ResponseWriter, _ *http.Request) {
+				writeJSON(w, http.StatusOK, map[string]interface{}{"outputs": []interface{}{}, "reference": "abc"})
+			},
+		})
+		if _, err := client.GetPaymentDestination(context.Background(), "mrz@handcash.io", 1000); !errors.Is(err, ErrDecodeFailure) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrDecodeFailure, err)
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		client := newMockClient(defaultUserAgent)
+		if _, err := client.GetPaymentDestination(context.Background(), "mrz@handcash.io", 0); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrInvalidInput, err)
+		}
+		if _, err := client.GetPaymentDestination(context.Background(), "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", 1000); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrInvalidInput, err)
+		}
+	})
+}
+
+// receiveTransactionHandler accepts the transaction if the reference is valid (rejects it otherwise)
+func receiveTransactionHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		transaction := new(P2PTransaction)
+		if err := json.NewDecoder(req.Body).Decode(transaction); err != nil {
+			t.Errorf("invalid P2P transaction request: %s", err.Error())
+		}
+		if transaction.Reference != "z0bac4ec-6f15-42de-9ef4-e60bfdabf4f7" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": "reference not found"})
+			return
+		}
+		note := "thanks"
+		if transaction.MetaData != nil {
+			note = "thanks " + transaction.MetaData.Sender
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"txid": "6e4f0b7f1c1b0a3c2d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c", "note": note})
+	}
+}
+
+// TestClient_SendP2PTransaction will test the SendP2PTransaction() method
+func TestClient_SendP2PTransaction(t *testing.T) {
+	t.Parallel()
+
+	capabilities := func(baseURL string) map[string]interface{} {
+		c := p2pCapabilities(baseURL)
+		c[BRFCP2PReceiveTransaction] = baseURL + "/api/v1/bsvalias/receive-transaction/{alias}@{domain.tld}"
+		return c
+	}
+	_, client := newMockPaymailServer(t, capabilities, map[string]http.HandlerFunc{
+		"/api/v1/bsvalias/receive-transaction/": receiveTransactionHandler(t),
+	})
+
+	t.Run("accepted", func(t *testing.T) {
+		response, err := client.SendP2PTransaction(context.Background(), "$mrz", &P2PTransaction{
+			Hex:       "0100000000",
+			MetaData:  &P2PTxMetaData{Note: "for the coffee", Sender: "sender@handcash.io"},
+			Reference: "z0bac4ec-6f15-42de-9ef4-e60bfdabf4f7",
+		})
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if response.TxID != "6e4f0b7f1c1b0a3c2d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c" {
+			t.Fatalf("%s Failed: unexpected txid [%s]", t.Name(), response.TxID)
+		} else if response.Note != "thanks sender@handcash.io" {
+			t.Fatalf("%s Failed: unexpected note [%s]", t.Name(), response.Note)
+		} else if response.LastRequest.StatusCode != http.StatusOK {
+			t.Fatalf("%s Failed: unexpected status [%d]", t.Name(), response.LastRequest.StatusCode)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		_, err := client.SendP2PTransaction(context.Background(), "mrz@handcash.io", &P2PTransaction{
+			Hex:       "0100000000",
+			Reference: "unknown",
+		})
+		var resolveErr *ResolveError
+		if !errors.Is(err, ErrTransactionRejected) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrTransactionRejected, err)
+		} else if !errors.As(err, &resolveErr) || resolveErr.Message != "reference not found" || resolveErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s Failed: expected the message and status from the receiver, received: [%v]", t.Name(), err)
+		}
+	})
+
+	t.Run("submitted once", func(t *testing.T) {
+		var submissions int64
+		_, retryClient := newMockPaymailServer(t, capabilities, map[string]http.HandlerFunc{
+			"/api/v1/bsvalias/receive-transaction/": func(w http.ResponseWriter, _ *http.Request) {
+				atomic.AddInt64(&submissions, 1)
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "database is down"})
+			},
+		})
+		retryClient.retrier = heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Millisecond, 0))
+		retryClient.retryCount = 2
+
+		response, err := retryClient.SendP2PTransaction(context.Background(), "mrz@handcash.io", &P2PTransaction{
+			Hex:       "0100000000",
+			Reference: "z0bac4ec-6f15-42de-9ef4-e60bfdabf4f7",
+		})
+		if !errors.Is(err, ErrUpstreamUnavailable) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrUpstreamUnavailable, err)
+		} else if count := atomic.LoadInt64(&submissions); count != 1 || response.LastRequest.Attempts != 1 {
+			t.Fatalf("%s Failed: expected [%d] attempt, received: [%d] (last request: %d)", t.Name(), 1, count, response.LastRequest.Attempts)
+		}
+	})
+
+	t.Run("invalid transaction", func(t *testing.T) {
+		var tests = []*P2PTransaction{
+			nil,
+			{Reference: "z0bac4ec-6f15-42de-9ef4-e60bfdabf4f7"},
+			{Hex: "not-hex", Reference: "z0bac4ec-6f15-42de-9ef4-e60bfdabf4f7"},
+			{Hex: "0100000000"},
+		}
+		for _, test := range tests {
+			if _, err := client.SendP2PTransaction(context.Background(), "mrz@handcash.io", test); !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("%s Failed: [%v] inputted and [%v] expected, received: [%v]", t.Name(), test, ErrInvalidInput, err)
+			}
+		}
+	})
+
+	t.Run("missing capability", func(t *testing.T) {
+		_, basicClient := newMockPaymailServer(t, basicCapabilities, nil)
+		if _, err := basicClient.SendP2PTransaction(context.Background(), "mrz@handcash.io", &P2PTransaction{
+			Hex:       "0100000000",
+			Reference: "z0bac4ec-6f15-42de-9ef4-e60bfdabf4f7",
+		}); !errors.Is(err, ErrCapabilityNotFound) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrCapabilityNotFound, err)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package polynym
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// secp256k1P is the prime of the secp256k1 field
+var secp256k1P, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+
+// PKIResponse is the response from the PKI capability (public key of the paymail)
+type PKIResponse struct {
+	BsvAlias    string       `json:"bsvalias"` // BsvAlias is the version of the specification
+	Handle      string       `json:"handle"`   // Handle is the paymail
+	LastRequest *LastRequest `json:"-"`        // LastRequest is the request to the paymail provider
+	PubKey      string       `json:"pubkey"`   // PubKey is the compressed public key (hex)
+}
+
+// VerifyPubKeyResponse is the response from the verify public key owner capability
+type VerifyPubKeyResponse struct {
+	BsvAlias    string       `json:"bsvalias"` // BsvAlias is the version of the specification
+	Handle      string       `json:"handle"`   // Handle is the paymail
+	LastRequest *LastRequest `json:"-"`        // LastRequest is the request to the paymail provider
+	Match       bool         `json:"match"`    // Match is true if the public key belongs to the paymail
+	PubKey      string       `json:"pubkey"`   // PubKey is the public key that was verified
+}
+
+// GetPublicKey will get the PKI public key of the paymail (or $handle, 1handle)
+//
+// The public key is validated as a compressed secp256k1 public key
+func (c *Client) GetPublicKey(ctx context.Context, handleOrPaymail string) (*PKIResponse, error) {
+	paymail, capabilities, err := c.paymailCapabilities(ctx, handleOrPaymail)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := capabilities.GetString(BRFCPki)
+	if len(endpoint) == 0 {
+		return nil, newResolveError(ErrCapabilityNotFound, nil, BRFCPki, nil)
+	}
+
+	// Get the public key
+	alias, domain := splitPaymail(paymail)
+	response := &PKIResponse{}
+	if response.LastRequest, err = c.paymailRequest(
+		ctx, http.MethodGet, expandTemplate(endpoint, alias, domain, ""), nil, response,
+	); err != nil {
+		return response, err
+	}
+
+	// Validate the public key
+	if err = ValidatePublicKey(response.PubKey); err != nil {
+		return response, newResolveError(ErrDecodeFailure, response.LastRequest, "invalid public key from provider", ErrInvalidPublicKey)
+	}
+	return response, nil
+}
+
+// VerifyPublicKeyOwner will verify that the public key belongs to the paymail (or $handle, 1handle)
+//
+// The public key must be a compressed secp256k1 public key (hex). If the handle or public key in the
+// response is not the one requested, the error will match ErrDecodeFailure.
+func (c *Client) VerifyPublicKeyOwner(ctx context.Context, handleOrPaymail, pubKey string) (*VerifyPubKeyResponse, error) {
+	if err := ValidatePublicKey(pubKey); err != nil {
+		return nil, err
+	}
+	paymail, capabilities, err := c.paymailCapabilities(ctx, handleOrPaymail)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := capabilities.GetString(BRFCVerifyPublicKeyOwner)
+	if len(endpoint) == 0 {
+		return nil, newResolveError(ErrCapabilityNotFound, nil, BRFCVerifyPublicKeyOwner, nil)
+	}
+
+	// Verify the public key
+	alias, domain := splitPaymail(paymail)
+	response := &VerifyPubKeyResponse{}
+	if response.LastRequest, err = c.paymailRequest(
+		ctx, http.MethodGet, expandTemplate(endpoint, alias, domain, strings.ToLower(pubKey)), nil, response,
+	); err != nil {
+		return response, err
+	}
+
+	// The response must be for the requested paymail and public key (otherwise the match means nothing)
+	if !strings.EqualFold(response.Handle, paymail) || !strings.EqualFold(response.PubKey, pubKey) {
+		return response, newResolveError(ErrDecodeFailure, response.LastRequest, "handle or pubkey in response does not match the request", nil)
+	}
+	return response, nil
+}
+
+// ValidatePublicKey will validate a compressed secp256k1 public key (hex)
+//
+// The key must be 33 bytes, start with 02 or 03 and the x coordinate must be on the curve
+func ValidatePublicKey(pubKey string) error {
+	decoded, err := hex.DecodeString(pubKey)
+	if err != nil {
+		return newResolveError(ErrInvalidInput, nil, "public key is not hex", ErrInvalidPublicKey)
+	} else if len(decoded) != 33 {
+		return newResolveError(ErrInvalidInput, nil, "public key must be 33 bytes (compressed)", ErrInvalidPublicKey)
+	} else if decoded[0] != 0x02 && decoded[0] != 0x03 {
+		return newResolveError(ErrInvalidInput, nil, "public key must start with 02 or 03 (compressed)", ErrInvalidPublicKey)
+	}
+
+	// The x coordinate must be in the field
+	x := new(big.Int).SetBytes(decoded[1:])
+	if x.Cmp(secp256k1P) >= 0 {
+		return newResolveError(ErrInvalidInput, nil, "public key is not on the curve", ErrInvalidPublicKey)
+	}
+
+	// y^2 = x^3 + 7 must have a square root (p = 3 mod 4, so y = (x^3 + 7)^((p+1)/4))
+	ySquared := new(big.Int).Exp(x, big.NewInt(3), secp256k1P)
+	ySquared.Add(ySquared, big.NewInt(7)).Mod(ySquared, secp256k1P)
+	exponent := new(big.Int).Add(secp256k1P, big.NewInt(1))
+	exponent.Rsh(exponent, 2)
+	y := new(big.Int).Exp(ySquared, exponent, secp256k1P)
+	if new(big.Int).Exp(y, big.NewInt(2), secp256k1P).Cmp(ySquared) != 0 {
+		return newResolveError(ErrInvalidInput, nil, "public key is not on the curve", ErrInvalidPublicKey)
+	}
+	return nil
+}
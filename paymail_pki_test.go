@@ -0,0 +1,179 @@
+package polynym
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// testPubKey is a valid compressed public key (the secp256k1 generator point)
+const testPubKey = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+// pkiCapabilities returns the capabilities with the PKI and verify public key owner endpoints
+func pkiCapabilities(baseURL string) map[string]interface{} {
+	return map[string]interface{}{
+		"pki":                    baseURL + "/api/v1/bsvalias/id/{alias}@{domain.tld}",
+		BRFCVerifyPublicKeyOwner: baseURL + "/api/v1/bsvalias/verify-pubkey/{alias}@{domain.tld}/{pubkey}",
+	}
+}
+
+// pkiHandler returns the public key for the mrz alias (and an invalid key for the bad alias)
+func pkiHandler(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/mrz@handcash.io"):
+		writeJSON(w, http.StatusOK, map[string]string{"bsvalias": "1.0", "handle": "mrz@handcash.io", "pubkey": testPubKey})
+	case strings.HasSuffix(req.URL.Path, "/bad@handcash.io"):
+		writeJSON(w, http.StatusOK, map[string]string{"bsvalias": "1.0", "handle": "bad@handcash.io", "pubkey": "04abcdef"})
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "paymail not found"})
+	}
+}
+
+// verifyHandler will match the test public key for the mrz alias
+//
+// The wrong@handcash.io paymail answers for a different paymail, the mismatch@handcash.io paymail for a different key
+func verifyHandler(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(req.URL.Path, "/")
+	handle, pubKey := parts[len(parts)-2], parts[len(parts)-1]
+	switch handle {
+	case "wrong@handcash.io":
+		handle = "mrz@handcash.io"
+	case "mismatch@handcash.io":
+		pubKey = "03" + pubKey[2:]
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"bsvalias": "1.0",
+		"handle":   handle,
+		"match":    handle == "mrz@handcash.io" && pubKey == testPubKey,
+		"pubkey":   pubKey,
+	})
+}
+
+// TestClient_GetPublicKey will test the GetPublicKey() method
+func TestClient_GetPublicKey(t *testing.T) {
+	t.Parallel()
+
+	_, client := newMockPaymailServer(t, pkiCapabilities, map[string]http.HandlerFunc{
+		"/api/v1/bsvalias/id/": pkiHandler,
+	})
+
+	// Create the list of tests
+	var tests = []struct {
+		input         string
+		expected      string
+		expectedError error
+	}{
+		{"$mrz", testPubKey, nil},
+		{"mrz@handcash.io", testPubKey, nil},
+		{"bad@handcash.io", "", ErrInvalidPublicKey},
+		{"unknown@handcash.io", "", ErrNotFound},
+		{"19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", "", ErrInvalidInput},
+	}
+
+	// Test all
+	for _, test := range tests {
+		output, err := client.GetPublicKey(context.Background(), test.input)
+		if test.expectedError != nil {
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.input, test.expectedError, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if output.PubKey != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expected, output.PubKey)
+		}
+	}
+
+	t.Run("missing capability", func(t *testing.T) {
+		_, basicClient := newMockPaymailServer(t, basicCapabilities, nil)
+		if _, err := basicClient.GetPublicKey(context.Background(), "mrz@handcash.io"); !errors.Is(err, ErrCapabilityNotFound) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrCapabilityNotFound, err)
+		}
+	})
+}
+
+// TestClient_VerifyPublicKeyOwner will test the VerifyPublicKeyOwner() method
+func TestClient_VerifyPublicKeyOwner(t *testing.T) {
+	t.Parallel()
+
+	_, client := newMockPaymailServer(t, pkiCapabilities, map[string]http.HandlerFunc{
+		"/api/v1/bsvalias/verify-pubkey/": verifyHandler,
+	})
+
+	// Create the list of tests
+	var tests = []struct {
+		input         string
+		pubKey        string
+		expectedMatch bool
+		expectedError error
+	}{
+		{"$mrz", testPubKey, true, nil},
+		{"other@handcash.io", testPubKey, false, nil},
+		{"mrz@handcash.io", strings.ToUpper(testPubKey), true, nil},
+		{"wrong@handcash.io", testPubKey, false, ErrDecodeFailure},
+		{"mismatch@handcash.io", testPubKey, false, ErrDecodeFailure},
+		{"mrz@handcash.io", "", false, ErrInvalidPublicKey},
+		{"mrz@handcash.io", "04" + testPubKey[2:], false, ErrInvalidPublicKey},
+	}
+
+	// Test all
+	for _, test := range tests {
+		output, err := client.VerifyPublicKeyOwner(context.Background(), test.input, test.pubKey)
+		if test.expectedError != nil {
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.input, test.expectedError, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if output.Match != test.expectedMatch {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.input, test.expectedMatch, output.Match)
+		}
+	}
+}
+
+// TestValidatePublicKey will test the ValidatePublicKey() method
+func TestValidatePublicKey(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		input         string
+		expectedError bool
+	}{
+		{testPubKey, false},
+		{"0379be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", false},
+		{"", true},
+		{"not-hex", true},
+		{"0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f817", true},
+		{"0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", true},
+		{"020000000000000000000000000000000000000000000000000000000000000005", true},
+		{"02fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc30", true},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if err := ValidatePublicKey(test.input); err == nil && test.expectedError {
+			t.Errorf("%s Failed: expected to throw an error, no error [%s] inputted", t.Name(), test.input)
+		} else if err != nil && !test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if err != nil && (!errors.Is(err, ErrInvalidPublicKey) || !errors.Is(err, ErrInvalidInput)) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.input, ErrInvalidPublicKey, err)
+		}
+	}
+}
+
+// ExampleValidatePublicKey example using ValidatePublicKey()
+func ExampleValidatePublicKey() {
+	fmt.Println(ValidatePublicKey(testPubKey) == nil)
+	// Output:true
+}
+
+// BenchmarkValidatePublicKey benchmarks the ValidatePublicKey method
+func BenchmarkValidatePublicKey(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = ValidatePublicKey(testPubKey)
+	}
+}
@@ -0,0 +1,37 @@
+package polynym
+
+import (
+	"context"
+	"net/http"
+)
+
+// PublicProfile is the response from the public profile capability
+type PublicProfile struct {
+	Avatar      string       `json:"avatar"` // Avatar is the URL of the avatar image
+	LastRequest *LastRequest `json:"-"`      // LastRequest is the request to the paymail provider
+	Name        string       `json:"name"`   // Name is the display name
+}
+
+// GetPublicProfile will get the public profile (name and avatar) of the paymail (or $handle, 1handle)
+//
+// The same conversion rules as GetAddress() are used for handles
+func (c *Client) GetPublicProfile(ctx context.Context, handleOrPaymail string) (*PublicProfile, error) {
+	paymail, capabilities, err := c.paymailCapabilities(ctx, handleOrPaymail)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := capabilities.GetString(BRFCPublicProfile)
+	if len(endpoint) == 0 {
+		return nil, newResolveError(ErrCapabilityNotFound, nil, BRFCPublicProfile, nil)
+	}
+
+	// Get the profile
+	alias, domain := splitPaymail(paymail)
+	profile := &PublicProfile{}
+	if profile.LastRequest, err = c.paymailRequest(
+		ctx, http.MethodGet, expandTemplate(endpoint, alias, domain, ""), nil, profile,
+	); err != nil {
+		return profile, err
+	}
+	return profile, nil
+}
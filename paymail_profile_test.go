@@ -0,0 +1,73 @@
+package polynym
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// profileCapabilities returns the capabilities with the public profile endpoint
+func profileCapabilities(baseURL string) map[string]interface{} {
+	return map[string]interface{}{
+		BRFCPublicProfile: baseURL + "/api/v1/bsvalias/public-profile/{alias}@{domain.tld}",
+	}
+}
+
+// profileHandler returns the profile for the known aliases
+func profileHandler(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/mrz@handcash.io"):
+		writeJSON(w, http.StatusOK, map[string]string{"avatar": "https://cloud.handcash.io/mrz.png", "name": "MrZ"})
+	case strings.HasSuffix(req.URL.Path, "/mrz@relayx.io"):
+		writeJSON(w, http.StatusOK, map[string]string{"avatar": "https://relayx.io/mrz.png", "name": "MrZ (RelayX)"})
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "paymail not found"})
+	}
+}
+
+// TestClient_GetPublicProfile will test the GetPublicProfile() method
+func TestClient_GetPublicProfile(t *testing.T) {
+	t.Parallel()
+
+	_, client := newMockPaymailServer(t, profileCapabilities, map[string]http.HandlerFunc{
+		"/api/v1/bsvalias/public-profile/": profileHandler,
+	})
+
+	// Create the list of tests
+	var tests = []struct {
+		input          string
+		expectedName   string
+		expectedAvatar string
+		expectedError  error
+	}{
+		{"$MrZ", "MrZ", "https://cloud.handcash.io/mrz.png", nil},
+		{"mrz@handcash.io", "MrZ", "https://cloud.handcash.io/mrz.png", nil},
+		{"1mrz", "MrZ (RelayX)", "https://relayx.io/mrz.png", nil},
+		{"unknown@handcash.io", "", "", ErrNotFound},
+		{"", "", "", ErrInvalidInput},
+		{"19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", "", "", ErrInvalidInput},
+	}
+
+	// Test all
+	for _, test := range tests {
+		output, err := client.GetPublicProfile(context.Background(), test.input)
+		if test.expectedError != nil {
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.input, test.expectedError, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if output.Name != test.expectedName || output.Avatar != test.expectedAvatar {
+			t.Errorf("%s Failed: [%s] inputted and [%s/%s] expected, received: [%s/%s]", t.Name(), test.input, test.expectedName, test.expectedAvatar, output.Name, output.Avatar)
+		}
+	}
+
+	t.Run("missing capability", func(t *testing.T) {
+		_, basicClient := newMockPaymailServer(t, basicCapabilities, nil)
+		if _, err := basicClient.GetPublicProfile(context.Background(), "mrz@handcash.io"); !errors.Is(err, ErrCapabilityNotFound) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrCapabilityNotFound, err)
+		}
+	})
+}
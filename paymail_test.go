@@ -0,0 +1,303 @@
+package polynym
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	// testScript is a P2PKH output script for the testAddress
+	testScript = "76a9140102030405060708090a0b0c0d0e0f101112131488ac"
+
+	// testAddress is the address for the testScript
+	testAddress = "16L5yRNPTuciSgXGHqYwn9N6NeoKqopAu"
+)
+
+// basicCapabilities returns the capabilities with the address resolution endpoint
+func basicCapabilities(baseURL string) map[string]interface{} {
+	return map[string]interface{}{
+		"paymentDestination": baseURL + "/api/v1/bsvalias/address/{alias}@{domain.tld}",
+		BRFCSenderValidation: false,
+	}
+}
+
+// addressHandler returns the output script for mrz@handcash.io and 833@twetch.me (404 for any other paymail)
+func addressHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"message": "method not allowed"})
+			return
+		}
+		body := new(addressResolutionRequest)
+		if err := json.NewDecoder(req.Body).Decode(body); err != nil || len(body.Dt) == 0 || len(body.SenderName) == 0 {
+			t.Errorf("invalid address resolution request: %v", body)
+		}
+		if !strings.HasSuffix(req.URL.Path, "/mrz@handcash.io") && !strings.HasSuffix(req.URL.Path, "/833@twetch.me") {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "paymail not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"output": testScript})
+	}
+}
+
+// TestClient_ResolvePaymail will test the ResolvePaymail() method
+func TestClient_ResolvePaymail(t *testing.T) {
+	t.Parallel()
+
+	_, client := newMockPaymailServer(t, basicCapabilities, map[string]http.HandlerFunc{
+		"/api/v1/bsvalias/address/": addressHandler(t),
+	})
+
+	// Create the list of tests
+	var tests = []struct {
+		input         string
+		expected      string
+		expectedError error
+	}{
+		{"mrz@handcash.io", testAddress, nil},
+		{"$MrZ", testAddress, nil},
+		{"19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", nil},
+		{"unknown@handcash.io", "", ErrNotFound},
+		{"@833", testAddress, nil},
+		{"@mrz", "", ErrInvalidInput},
+		{"", "", ErrInvalidInput},
+	}
+
+	// Test all
+	for _, test := range tests {
+		output, err := client.ResolvePaymail(context.Background(), test.input)
+		if test.expectedError != nil {
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.input, test.expectedError, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if output.Address != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expected, output.Address)
+		}
+	}
+}
+
+// TestClient_ResolvePaymail_SenderHandle will test the sender handle of the address resolution request
+func TestClient_ResolvePaymail_SenderHandle(t *testing.T) {
+	t.Parallel()
+
+	var received string
+	server, client := newMockPaymailServer(t, basicCapabilities, map[string]http.HandlerFunc{
+		"/api/v1/bsvalias/address/": func(w http.ResponseWriter, req *http.Request) {
+			body := make(map[string]interface{})
+			_ = json.NewDecoder(req.Body).Decode(&body)
+			received = fmt.Sprint(body["senderHandle"])
+			writeJSON(w, http.StatusOK, map[string]string{"output": testScript})
+		},
+	})
+	if _, err := client.ResolvePaymail(context.Background(), "mrz@handcash.io"); err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	} else if received != "ops@example.com" {
+		t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "ops@example.com", received)
+	}
+
+	// The sender handle is required (no request is sent without it)
+	var requests int64
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client.paymailSender = ""
+	if _, err := client.ResolvePaymail(context.Background(), "mrz@handcash.io"); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrInvalidInput, err)
+	} else if atomic.LoadInt64(&requests) != 0 {
+		t.Fatalf("%s Failed: expected no requests, received: [%d]", t.Name(), requests)
+	}
+}
+
+// TestClient_ResolvePaymail_Errors will test the errors from the paymail provider
+func TestClient_ResolvePaymail_Errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing capability", func(t *testing.T) {
+		_, client := newMockPaymailServer(t, func(string) map[string]interface{} {
+			return map[string]interface{}{BRFCPki: "https://example.com/{alias}@{domain.tld}/id"}
+		}, nil)
+		if _, err := client.ResolvePaymail(context.Background(), "mrz@handcash.io"); !errors.Is(err, ErrCapabilityNotFound) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrCapabilityNotFound, err)
+		}
+	})
+
+	t.Run("sender validation", func(t *testing.T) {
+		_, client := newMockPaymailServer(t, func(baseURL string) map[string]interface{} {
+			capabilities := basicCapabilities(baseURL)
+			capabilities[BRFCSenderValidation] = true
+			return capabilities
+		}, map[string]http.HandlerFunc{
+			"/api/v1/bsvalias/address/": func(http.ResponseWriter, *http.Request) {
+				t.Errorf("%s Failed: the unsigned request should not be sent", t.Name())
+			},
+		})
+		if _, err := client.ResolvePaymail(context.Background(), "mrz@handcash.io"); !errors.Is(err, ErrCapabilityNotFound) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrCapabilityNotFound, err)
+		}
+	})
+
+	t.Run("unsupported output script", func(t *testing.T) {
+		_, client := newMockPaymailServer(t, basicCapabilities, map[string]http.HandlerFunc{
+			"/api/v1/bsvalias/address/": func(w http.ResponseWriter, _ *http.Request) {
+				writeJSON(w, http.StatusOK, map[string]string{"output": "006a0568656c6c6f"})
+			},
+		})
+		_, err := client.ResolvePaymail(context.Background(), "mrz@handcash.io")
+		var resolveErr *ResolveError
+		if !errors.Is(err, ErrDecodeFailure) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrDecodeFailure, err)
+		} else if !errors.As(err, &resolveErr) || resolveErr.LastRequest == nil || resolveErr.StatusCode != http.StatusOK {
+			t.Fatalf("%s Failed: expected the last request on the error", t.Name())
+		}
+	})
+
+	t.Run("provider error", func(t *testing.T) {
+		_, client := newMockPaymailServer(t, basicCapabilities, map[string]http.HandlerFunc{
+			"/api/v1/bsvalias/address/": func(w http.ResponseWriter, _ *http.Request) {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "database is down"})
+			},
+		})
+		_, err := client.ResolvePaymail(context.Background(), "mrz@handcash.io")
+		var resolveErr *ResolveError
+		if !errors.Is(err, ErrUpstreamUnavailable) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrUpstreamUnavailable, err)
+		} else if !errors.As(err, &resolveErr) || resolveErr.Message != "database is down" {
+			t.Fatalf("%s Failed: expected the message from the provider, received: [%v]", t.Name(), err)
+		}
+	})
+
+	t.Run("request rejected by the provider", func(t *testing.T) {
+		_, client := newMockPaymailServer(t, basicCapabilities, map[string]http.HandlerFunc{
+			"/api/v1/bsvalias/address/": func(w http.ResponseWriter, _ *http.Request) {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"message": "senderHandle and dt are required"})
+			},
+		})
+		_, err := client.ResolvePaymail(context.Background(), "mrz@handcash.io")
+		if !errors.Is(err, ErrInvalidInput) || errors.Is(err, ErrUpstreamUnavailable) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrInvalidInput, err)
+		} else if !strings.Contains(err.Error(), "senderHandle and dt are required") {
+			t.Fatalf("%s Failed: expected the message from the provider, received: [%v]", t.Name(), err)
+		}
+	})
+
+	t.Run("invalid capabilities", func(t *testing.T) {
+		server, client := newMockPaymailServer(t, basicCapabilities, nil)
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("<html></html>"))
+		})
+		if _, err := client.GetCapabilities(context.Background(), "handcash.io"); !errors.Is(err, ErrDecodeFailure) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrDecodeFailure, err)
+		}
+	})
+}
+
+// TestClient_GetCapabilities will test the GetCapabilities() method
+func TestClient_GetCapabilities(t *testing.T) {
+	t.Parallel()
+
+	server, client := newMockPaymailServer(t, basicCapabilities, nil)
+
+	capabilities, err := client.GetCapabilities(context.Background(), "MrZ@HandCash.io")
+	if err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	} else if capabilities.BsvAlias != "1.0" {
+		t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "1.0", capabilities.BsvAlias)
+	} else if capabilities.GetString(BRFCPaymentDestination) != server.URL+"/api/v1/bsvalias/address/{alias}@{domain.tld}" {
+		t.Fatalf("%s Failed: unexpected payment destination [%s]", t.Name(), capabilities.GetString(BRFCPaymentDestination))
+	} else if !capabilities.Has(BRFCSenderValidation) || capabilities.GetBool(BRFCSenderValidation) {
+		t.Fatalf("%s Failed: expected sender validation to be found and false", t.Name())
+	} else if capabilities.Has(BRFCPki) || len(capabilities.GetString(BRFCPki)) > 0 {
+		t.Fatalf("%s Failed: expected pki not to be found", t.Name())
+	}
+
+	if _, err = client.GetCapabilities(context.Background(), "localhost"); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrInvalidInput, err)
+	}
+}
+
+// TestClient_lookupPaymailHost will test the SRV lookup (and the fallback to the domain)
+func TestClient_lookupPaymailHost(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		dns           *mockDNS
+		trustSRV      bool
+		expected      string
+		found         bool
+		expectedError bool
+	}{
+		{&mockDNS{host: "www.handcash.io", port: 443}, false, "www.handcash.io", true, false},
+		{&mockDNS{host: "handcash.io", port: 8443}, false, "handcash.io:8443", true, false},
+		{&mockDNS{host: "WWW.HandCash.io", port: 443}, false, "www.handcash.io", true, false},
+		{&mockDNS{host: "www.paymail.com", port: 443}, false, "handcash.io", true, true},
+		{&mockDNS{host: "evilhandcash.io", port: 443}, false, "handcash.io", true, true},
+		{&mockDNS{host: "www.paymail.com", port: 443}, true, "www.paymail.com", true, false},
+		{&mockDNS{host: "www.paymail.com", port: 8443}, true, "www.paymail.com:8443", true, false},
+		{&mockDNS{err: fmt.Errorf("no such host")}, false, "handcash.io", false, true},
+	}
+
+	// Test all
+	for _, test := range tests {
+		client := &Client{dnsResolver: test.dns, paymailTrustSRV: test.trustSRV}
+		if host, srv, err := client.lookupPaymailHost(context.Background(), "handcash.io"); host != test.expected {
+			t.Errorf("%s Failed: [%s] expected, received: [%s]", t.Name(), test.expected, host)
+		} else if (srv != nil) != test.found {
+			t.Errorf("%s Failed: expected the SRV record found [%v]", t.Name(), test.found)
+		} else if (err != nil) != test.expectedError {
+			t.Errorf("%s Failed: [%s] expected error [%v], received: [%v]", t.Name(), test.dns.host, test.expectedError, err)
+		}
+	}
+}
+
+// TestGetAddress_PaymailFallback will test resolving natively when Polynym is unavailable
+func TestGetAddress_PaymailFallback(t *testing.T) {
+	t.Parallel()
+
+	_, client := newMockPaymailServer(t, basicCapabilities, map[string]http.HandlerFunc{
+		"/api/v1/bsvalias/address/": addressHandler(t),
+	})
+	client.httpClient = &mockHTTPRouter{other: client.httpClient, polynym: &mockHTTPUnavailable{}}
+
+	// Fallback disabled
+	if _, err := client.GetAddress("$mrz"); !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrUpstreamUnavailable, err)
+	}
+
+	// Fallback enabled
+	client.paymailFallback = true
+	if output, err := client.GetAddress("$mrz"); err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	} else if output.Address != testAddress {
+		t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), testAddress, output.Address)
+	} else if !strings.Contains(output.LastRequest.URL, "/api/v1/bsvalias/address/mrz@handcash.io") {
+		t.Fatalf("%s Failed: unexpected url [%s]", t.Name(), output.LastRequest.URL)
+	}
+
+	// Twetch user ids are resolved using their paymail
+	if output, err := client.GetAddress("@833"); err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	} else if !strings.Contains(output.LastRequest.URL, "/api/v1/bsvalias/address/833@twetch.me") {
+		t.Fatalf("%s Failed: unexpected url [%s]", t.Name(), output.LastRequest.URL)
+	}
+
+	// Fallback also fails (the original error is returned)
+	if _, err := client.GetAddress("unknown@handcash.io"); !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrUpstreamUnavailable, err)
+	}
+
+	// Fallback without a sender handle (not used, the original error is returned)
+	client.paymailSender = ""
+	if _, err := client.GetAddress("$mrz"); !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrUpstreamUnavailable, err)
+	}
+}
@@ -4,7 +4,7 @@ Package polynym is the unofficial golang implementation for the Polynym API
 Example:
 
 // Create a new client
-client, _ := polynym.NewClient(nil)
+client := polynym.NewClient(nil)
 
 // Get address
 resp, _ := client.GetAddress("1mrz")
@@ -17,31 +17,129 @@ package polynym
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // GetAddressResponse is what polynym returns (success or fail)
 type GetAddressResponse struct {
 	Address      string       `json:"address"`
+	CacheHit     bool         `json:"-"`
 	ErrorMessage string       `json:"error"`
 	LastRequest  *LastRequest `json:"-"`
 }
 
 // GetAddress returns the address of a given 1handle, $handcash, paymail, Twetch user id or BitcoinSV address
-func GetAddress(client Client, handleOrPaymail string) (response *GetAddressResponse, err error) {
+func (c *Client) GetAddress(handleOrPaymail string) (response *GetAddressResponse, err error) {
+	return c.GetAddressWithContext(context.Background(), handleOrPaymail)
+}
+
+// GetAddressWithContext returns the address of a given 1handle, $handcash, paymail, Twetch user id or BitcoinSV address
+//
+// The context is used for the request, the retries and the back-off between them. If the context
+// is canceled the error will match context.Canceled, if the deadline passes it will match context.DeadlineExceeded
+//
+// BitcoinSV addresses are validated locally and returned without a request. If the PaymailFallback
+// option is set (with a PaymailSenderHandle), paymails are resolved natively when Polynym is unavailable (see ResolvePaymail).
+//
+// All errors are a *ResolveError and match one of the sentinel errors (ErrNotFound, ErrInvalidInput, etc)
+func (c *Client) GetAddressWithContext(ctx context.Context, handleOrPaymail string) (response *GetAddressResponse, err error) {
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, SpanResolve)
 
-	// Convert handle to paymail if detected
-	if strings.Contains(handleOrPaymail, "$") {
-		handleOrPaymail = HandCashConvert(handleOrPaymail, false)
-	} else if strings.HasPrefix(handleOrPaymail, "1") && len(handleOrPaymail) < 25 {
-		handleOrPaymail = RelayXConvert(handleOrPaymail)
+	// Detect the type of identifier and convert handles to paymails
+	idType, normalized, classifyErr := c.classify(handleOrPaymail)
+
+	// Report the lookup to the observer, the tracer and the logger (if set)
+	ctx = c.withLogFields(ctx, idType, normalized)
+	c.log(ctx, LogLevelDebug, "lookup started", map[string]interface{}{})
+	defer func() {
+		duration := time.Since(start)
+		c.observeResolution(idType, response, err, duration)
+		c.logLookup(ctx, response, err, duration)
+		endResolveSpan(span, idType, response, err)
+	}()
+
+	// Valid addresses are returned as-is (no request is sent, so the URL is empty)
+	if classifyErr == nil && idType == IdentifierAddress {
+		response = &GetAddressResponse{
+			Address: normalized,
+			LastRequest: &LastRequest{
+				Method:     http.MethodGet,
+				StatusCode: http.StatusOK,
+			},
+		}
+		return
 	}
 
 	// Set the API url
-	// todo: beta is temporary, and only used via the method directly
-	reqURL := fmt.Sprintf("%s/%s/%s", apiEndpoint, "getAddress", handleOrPaymail)
+	reqURL := fmt.Sprintf("%s/%s/%s", c.endpoint(), "getAddress", normalized)
+
+	// Check for a valid value
+	if classifyErr != nil {
+		response = &GetAddressResponse{
+			LastRequest: &LastRequest{
+				Method:     http.MethodGet,
+				StatusCode: http.StatusBadRequest,
+				URL:        reqURL,
+			},
+		}
+		err = withLastRequest(classifyErr, response.LastRequest)
+		return
+	}
+
+	// Check the cache (if enabled)
+	if entry := c.cacheGet(ctx, normalized); entry != nil {
+		c.log(ctx, LogLevelDebug, "cache hit", map[string]interface{}{"status": entry.StatusCode})
+		return entry.toResponse()
+	} else if c.cache != nil {
+		c.log(ctx, LogLevelDebug, "cache miss", map[string]interface{}{})
+	}
+
+	// Resolve using Polynym and store the result (if enabled), concurrent lookups share one request
+	if response, err = c.flights.do(ctx, normalized, func(ctx context.Context) (*GetAddressResponse, error) {
+		flightResponse, flightErr := c.getAddress(ctx, reqURL)
+		if flightErr != nil && c.shouldFallback(ctx, idType, flightErr) {
+			if fallbackResponse, fallbackErr := c.resolvePaymail(ctx, normalized); fallbackErr == nil {
+				flightResponse, flightErr = fallbackResponse, nil
+			}
+		}
+		c.cacheSet(ctx, normalized, flightResponse, flightErr)
+		return flightResponse, flightErr
+	}); response == nil {
+
+		// The caller gave up waiting on the lookup
+		response = &GetAddressResponse{
+			LastRequest: &LastRequest{
+				Method: http.MethodGet,
+				URL:    reqURL,
+			},
+		}
+		err = withLastRequest(err, response.LastRequest)
+	}
+	return
+}
+
+// shouldFallback returns true if the paymail should be resolved natively after Polynym failed
+//
+// Only failures of Polynym itself are retried (not found results are trusted), if the fallback
+// also fails the original error is returned. The fallback requires the PaymailSenderHandle.
+func (c *Client) shouldFallback(ctx context.Context, idType IdentifierType, err error) bool {
+	if !c.paymailFallback || len(c.paymailSender) == 0 || ctx.Err() != nil {
+		return false
+	}
+	if idType != IdentifierPaymail && !c.handleRegistry().isHandleType(idType) {
+		return false
+	}
+	return errors.Is(err, ErrUpstreamUnavailable) || errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, ErrTransportFailure) || errors.Is(err, ErrDecodeFailure)
+}
+
+// getAddress will fire the request to Polynym and decode the response
+func (c *Client) getAddress(ctx context.Context, reqURL string) (response *GetAddressResponse, err error) {
 
 	// Store for debugging purposes
 	response = &GetAddressResponse{
@@ -50,30 +148,28 @@ func GetAddress(client Client, handleOrPaymail string) (response *GetAddressResp
 			URL:    reqURL,
 		},
 	}
-
-	// Check for a value
-	if len(handleOrPaymail) == 0 {
-		response.LastRequest.StatusCode = http.StatusBadRequest
-		err = fmt.Errorf("missing handle or paymail to resolve")
-		return
-	}
+	defer func() {
+		c.logDecodeFailure(ctx, response.LastRequest, err)
+	}()
 
 	// Start the request
 	var req *http.Request
-	if req, err = http.NewRequestWithContext(context.Background(), http.MethodGet, reqURL, nil); err != nil {
+	if req, err = http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil); err != nil {
+		err = newResolveError(ErrInvalidInput, response.LastRequest, "", err)
 		return
 	}
 
 	// Set the header (user agent is in case they block default Go user agents)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", client.UserAgent)
+	req.Header.Set("User-Agent", c.UserAgent)
 
 	// Fire the request
 	var resp *http.Response
-	if resp, err = client.httpClient.Do(req); err != nil {
+	if resp, err = c.doRequest(req, response.LastRequest, c.retryCount); err != nil {
 		if resp != nil {
 			response.LastRequest.StatusCode = resp.StatusCode
 		}
+		err = newResolveError(ErrTransportFailure, response.LastRequest, "", err)
 		return
 	}
 
@@ -90,33 +186,53 @@ func GetAddress(client Client, handleOrPaymail string) (response *GetAddressResp
 
 	// Handle errors
 	if resp.StatusCode != http.StatusOK {
-
-		// Decode the error message
-		if resp.StatusCode == http.StatusBadRequest {
-			if resp.Body == nil {
-				err = fmt.Errorf("no response body found")
-				return
-			}
-			if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
-				return
-			}
-			if len(response.ErrorMessage) == 0 {
-				response.ErrorMessage = "unknown error resolving address"
-			}
-			err = fmt.Errorf("%s", response.ErrorMessage)
-		} else {
-			err = fmt.Errorf("bad response from polynym: %d", resp.StatusCode)
-		}
-
+		err = statusError(resp, response)
 		return
 	}
 
 	// Try and decode the response
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		err = newResolveError(ErrDecodeFailure, response.LastRequest, "", err)
+	} else if len(response.Address) == 0 {
+		err = newResolveError(ErrDecodeFailure, response.LastRequest, "missing address in response", nil)
+	}
 
 	return
 }
 
+// statusError will create the error for a non-200 response from polynym
+func statusError(resp *http.Response, response *GetAddressResponse) error {
+
+	// Determine the kind of error from the status
+	kind := ErrUpstreamUnavailable
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound:
+		kind = ErrNotFound
+	case http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	}
+
+	// Polynym returns the reason for a bad request in the body
+	if resp.StatusCode == http.StatusBadRequest {
+		if resp.Body == nil {
+			return newResolveError(ErrDecodeFailure, response.LastRequest, "no response body found", nil)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			return newResolveError(ErrDecodeFailure, response.LastRequest, "", err)
+		}
+		if len(response.ErrorMessage) == 0 {
+			response.ErrorMessage = "unknown error resolving address"
+		}
+		return newResolveError(kind, response.LastRequest, response.ErrorMessage, nil)
+	}
+
+	// Try to get the message from the body (not required)
+	if resp.Body != nil {
+		_ = json.NewDecoder(resp.Body).Decode(&response)
+	}
+	return newResolveError(kind, response.LastRequest, response.ErrorMessage, nil)
+}
+
 // HandCashConvert now converts $handle to paymail: handle@handcash.io or handle@beta.handcash.io
 func HandCashConvert(handle string, isBeta bool) string {
 	if strings.HasPrefix(handle, "$") {
@@ -128,10 +244,34 @@ func HandCashConvert(handle string, isBeta bool) string {
 	return handle
 }
 
-// RelayXConvert now converts 1handle to paymail: handle@relayx.io
-func RelayXConvert(handle string) string {
-	if strings.HasPrefix(handle, "1") && len(handle) < 25 {
-		return strings.ToLower(strings.Replace(handle, "1", "", -1)) + "@relayx.io"
+// TwetchConvert converts a Twetch user id to paymail: @833 -> 833@twetch.me
+//
+// An error is returned if the user id is missing the @ prefix, is not a number (without
+// leading zeros) or is too long
+func TwetchConvert(userID string) (string, error) {
+	id := strings.TrimPrefix(userID, "@")
+	if !strings.HasPrefix(userID, "@") || !isDigits(id) || strings.HasPrefix(id, "0") || len(id) > twetchMaxLength {
+		return "", newResolveError(ErrInvalidInput, nil, "invalid Twetch user id: "+userID, nil)
 	}
-	return handle
+	return id + "@twetch.me", nil
+}
+
+// RelayXConvert converts a 1handle to paymail: handle@relayx.io
+//
+// Only the leading "1" is removed (1user1 -> user1@relayx.io) and the handle is lowercased.
+// An error is returned if the handle is missing the prefix, is too short or too long, uses invalid
+// characters (a-z, 0-9, "-", "_" and "." are allowed) or is a valid BitcoinSV address
+func RelayXConvert(handle string) (string, error) {
+	if !strings.HasPrefix(handle, "1") {
+		return "", newResolveError(ErrInvalidInput, nil, "invalid 1handle (missing the 1 prefix): "+handle, nil)
+	} else if IsValidAddress(handle) {
+		return "", newResolveError(ErrInvalidInput, nil, "invalid 1handle (is a BitcoinSV address): "+handle, nil)
+	} else if len(handle) < relayXMinLength || len(handle) > relayXMaxLength {
+		return "", newResolveError(ErrInvalidInput, nil, fmt.Sprintf(
+			"invalid 1handle (must be %d to %d characters): %s", relayXMinLength, relayXMaxLength, handle,
+		), nil)
+	} else if !isValidHandle(handle[1:]) {
+		return "", newResolveError(ErrInvalidInput, nil, "invalid 1handle (invalid characters): "+handle, nil)
+	}
+	return strings.ToLower(handle[1:]) + "@relayx.io", nil
 }
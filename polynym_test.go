@@ -1,14 +1,19 @@
 package polynym
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
+
+	"github.com/gojektech/heimdall/v6"
 )
 
 // newMockClient will create a new mock client for testing
-func newMockClient(userAgent string) Client {
-	return Client{
+func newMockClient(userAgent string) *Client {
+	return &Client{
 		httpClient: &mockHTTP{},
 		UserAgent:  userAgent,
 	}
@@ -29,9 +34,9 @@ func TestGetAddress(t *testing.T) {
 		statusCode    int
 	}{
 		{"", "", true, http.StatusBadRequest},
-		{"error", "", true, http.StatusBadRequest},
-		{"bad-poly-response", "", true, http.StatusBadRequest},
-		{"bad-poly-status", "", true, http.StatusBadRequest},
+		{"error@test.com", "", true, http.StatusBadRequest},
+		{"bad-poly-response@test.com", "", true, http.StatusBadRequest},
+		{"bad-poly-status@test.com", "", true, http.StatusBadRequest},
 		{"doesnotexist@handcash.io", "", true, http.StatusBadRequest},
 		{"$mr-z", "124dwBFyFtkcNXGfVWQroGcT9ybnpQ3G3Z", false, http.StatusOK},
 		{"19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", false, http.StatusOK},
@@ -45,7 +50,7 @@ func TestGetAddress(t *testing.T) {
 
 	// Test all
 	for _, test := range tests {
-		if output, err := GetAddress(client, test.input); err == nil && test.expectedError {
+		if output, err := client.GetAddress(test.input); err == nil && test.expectedError {
 			t.Errorf("%s Failed: expected to throw an error, no error [%s] inputted and [%s] expected", t.Name(), test.input, test.expected)
 		} else if err != nil && !test.expectedError {
 			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%v] error [%s]", t.Name(), test.input, test.expected, output, err.Error())
@@ -59,10 +64,127 @@ func TestGetAddress(t *testing.T) {
 	}
 }
 
-// ExampleGetAddress example using GetAddress()
-func ExampleGetAddress() {
+// TestGetAddressWithContext tests the GetAddressWithContext()
+func TestGetAddressWithContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid address", func(t *testing.T) {
+		client := newMockClient(defaultUserAgent)
+		output, err := client.GetAddressWithContext(context.Background(), "1mrz")
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if output.Address != "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa" {
+			t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa", output.Address)
+		}
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		client := newMockClient(defaultUserAgent)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := client.GetAddressWithContext(ctx, "1mrz"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s Failed: expected error [%v] received: [%v]", t.Name(), context.Canceled, err)
+		}
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		client := &Client{httpClient: &mockHTTPBlocking{}, UserAgent: defaultUserAgent}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := client.GetAddressWithContext(ctx, "1mrz"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%s Failed: expected error [%v] received: [%v]", t.Name(), context.DeadlineExceeded, err)
+		}
+	})
+
+	t.Run("canceled during back-off", func(t *testing.T) {
+		mock := &mockHTTPUnavailable{}
+		client := &Client{
+			httpClient: mock,
+			retrier:    heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Hour, 0)),
+			retryCount: 3,
+			UserAgent:  defaultUserAgent,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := client.GetAddressWithContext(ctx, "1mrz"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%s Failed: expected error [%v] received: [%v]", t.Name(), context.DeadlineExceeded, err)
+		} else if mock.attempts != 1 {
+			t.Fatalf("%s Failed: expected [%d] attempts, received: [%d]", t.Name(), 1, mock.attempts)
+		}
+	})
+}
+
+// TestGetAddress_Errors tests the errors returned from GetAddress()
+func TestGetAddress_Errors(t *testing.T) {
+	t.Parallel()
+
+	// Create a mock client
+	client := newMockClient(defaultUserAgent)
+
+	// Create the list of tests
+	var tests = []struct {
+		input      string
+		kind       error
+		message    string
+		statusCode int
+	}{
+		{"", ErrInvalidInput, "missing handle or paymail to resolve", http.StatusBadRequest},
+		{"error@test.com", ErrTransportFailure, "", http.StatusBadRequest},
+		{"bad-poly-response@test.com", ErrNotFound, "unknown error resolving address", http.StatusBadRequest},
+		{"bad-poly-json@test.com", ErrDecodeFailure, "", http.StatusOK},
+		{"rate-limited@test.com", ErrRateLimited, "too many requests", http.StatusTooManyRequests},
+		{"doesnotexist@handcash.io", ErrNotFound, "$handle not found", http.StatusBadRequest},
+		{"bad@paymailaddress.com", ErrNotFound, "PayMail not found", http.StatusBadRequest},
+		{"c6ZqP5Tb22KJuvSAbjNkoi", ErrInvalidInput, "unrecognized handle, paymail or address: c6ZqP5Tb22KJuvSAbjNkoi", http.StatusBadRequest},
+		{"$mr z", ErrInvalidInput, "invalid $handle: $mr z", http.StatusBadRequest},
+	}
+
+	// Test all
+	for _, test := range tests {
+		_, err := client.GetAddress(test.input)
+		var resolveErr *ResolveError
+		if !errors.Is(err, test.kind) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.input, test.kind, err)
+		} else if !errors.As(err, &resolveErr) {
+			t.Errorf("%s Failed: [%s] inputted and expected a ResolveError, received: [%T]", t.Name(), test.input, err)
+		} else if resolveErr.Message != test.message {
+			t.Errorf("%s Failed: [%s] inputted and [%s] message expected, received: [%s]", t.Name(), test.input, test.message, resolveErr.Message)
+		} else if resolveErr.StatusCode != test.statusCode {
+			t.Errorf("%s Failed: [%s] inputted and [%d] status expected, received: [%d]", t.Name(), test.input, test.statusCode, resolveErr.StatusCode)
+		} else if resolveErr.LastRequest == nil {
+			t.Errorf("%s Failed: [%s] inputted and expected the last request", t.Name(), test.input)
+		}
+	}
+
+	t.Run("upstream unavailable", func(t *testing.T) {
+		unavailable := &Client{httpClient: &mockHTTPUnavailable{}, UserAgent: defaultUserAgent}
+		if _, err := unavailable.GetAddress("1mrz"); !errors.Is(err, ErrUpstreamUnavailable) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), ErrUpstreamUnavailable, err)
+		}
+	})
+}
+
+// TestGetAddress_Endpoint tests the API endpoint and HandCash network used by GetAddress()
+func TestGetAddress_Endpoint(t *testing.T) {
+	t.Parallel()
+
+	client := newMockClient(defaultUserAgent)
+	client.apiEndpoint = "http://localhost:3000"
+	client.handCashBeta = true
+
+	_, err := client.GetAddress("$mr-z")
+	var resolveErr *ResolveError
+	if !errors.As(err, &resolveErr) {
+		t.Fatalf("%s Failed: expected a ResolveError, received: [%v]", t.Name(), err)
+	} else if resolveErr.LastRequest.URL != "http://localhost:3000/getAddress/mr-z@beta.handcash.io" {
+		t.Fatalf("%s Failed: unexpected url [%s]", t.Name(), resolveErr.LastRequest.URL)
+	}
+}
+
+// ExampleClient_GetAddress example using GetAddress()
+func ExampleClient_GetAddress() {
 	client := newMockClient(defaultUserAgent)
-	resp, _ := GetAddress(client, "16ZqP5Tb22KJuvSAbjNkoiZs13mmRmexZA")
+	resp, _ := client.GetAddress("16ZqP5Tb22KJuvSAbjNkoiZs13mmRmexZA")
 	fmt.Println(resp.Address)
 	// Output:16ZqP5Tb22KJuvSAbjNkoiZs13mmRmexZA
 }
@@ -93,6 +215,18 @@ func TestHandCashConvert(t *testing.T) {
 	}
 }
 
+// TestResolver tests using the Client as a Resolver
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	var resolver Resolver = newMockClient(defaultUserAgent)
+	if output, err := resolver.GetAddress("$mr-z"); err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	} else if output.Address != "124dwBFyFtkcNXGfVWQroGcT9ybnpQ3G3Z" {
+		t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "124dwBFyFtkcNXGfVWQroGcT9ybnpQ3G3Z", output.Address)
+	}
+}
+
 // ExampleHandCashConvert example using HandCashConvert()
 func ExampleHandCashConvert() {
 	paymail := HandCashConvert("$mr-z", false)
@@ -107,42 +241,112 @@ func BenchmarkHandCashConvert(b *testing.B) {
 	}
 }
 
+// TestTwetchConvert will test the TwetchConvert() method
+func TestTwetchConvert(t *testing.T) {
+	t.Parallel()
+
+	// Create the list of tests
+	var tests = []struct {
+		input         string
+		expected      string
+		expectedError bool
+	}{
+		{"@833", "833@twetch.me", false},
+		{"@1", "1@twetch.me", false},
+		{"@123456789012", "123456789012@twetch.me", false},
+		{"@1234567890123", "", true},
+		{"@0", "", true},
+		{"@0833", "", true},
+		{"@", "", true},
+		{"@mrz", "", true},
+		{"@-833", "", true},
+		{"833", "", true},
+		{"833@twetch.me", "", true},
+	}
+
+	// Test all
+	for _, test := range tests {
+		if output, err := TwetchConvert(test.input); err == nil && test.expectedError {
+			t.Errorf("%s Failed: expected to throw an error, no error [%s] inputted", t.Name(), test.input)
+		} else if err != nil && !test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if err != nil && !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s Failed: [%s] inputted, expected [%v] received: [%v]", t.Name(), test.input, ErrInvalidInput, err)
+		} else if output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expected, output)
+		}
+	}
+}
+
+// ExampleTwetchConvert example using TwetchConvert()
+func ExampleTwetchConvert() {
+	paymail, _ := TwetchConvert("@833")
+	fmt.Println(paymail)
+	// Output:833@twetch.me
+}
+
+// BenchmarkTwetchConvert benchmarks the TwetchConvert method
+func BenchmarkTwetchConvert(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = TwetchConvert("@833")
+	}
+}
+
 // TestRelayXConvert will test the RelayXConvert() method
 func TestRelayXConvert(t *testing.T) {
 	t.Parallel()
 
 	// Create the list of tests
 	var tests = []struct {
-		input    string
-		expected string
+		input         string
+		expected      string
+		expectedError bool
 	}{
-		{"1mr-z", "mr-z@relayx.io"},
-		{"invalid1mr-z", "invalid1mr-z"},
-		{"1", "@relayx.io"},
-		{"1mrz", "mrz@relayx.io"},
-		{"1handle", "handle@relayx.io"},
-		{"1misterz", "misterz@relayx.io"},
-		{"mrz@relayx.io", "mrz@relayx.io"},
+		{"1mr-z", "mr-z@relayx.io", false},
+		{"1mrz", "mrz@relayx.io", false},
+		{"1MrZ", "mrz@relayx.io", false},
+		{"1handle", "handle@relayx.io", false},
+		{"1misterz", "misterz@relayx.io", false},
+		{"1user1", "user1@relayx.io", false},
+		{"11", "1@relayx.io", false},
+		{"1first.last_name", "first.last_name@relayx.io", false},
+		{"1abcdefghijklmnopqrstuvw", "abcdefghijklmnopqrstuvw@relayx.io", false},
+		{"1abcdefghijklmnopqrstuvwx", "", true},
+		{"1", "", true},
+		{"", "", true},
+		{"invalid1mr-z", "", true},
+		{"mrz@relayx.io", "", true},
+		{"1mr z", "", true},
+		{"1mr$z", "", true},
+		{"1mr@z", "", true},
+		{"1mrž", "", true},
+		{"16ZqP5Tb22KJuvSAbjNkoiZs13mmRmexZA", "", true},
 	}
 
 	// Test all
 	for _, test := range tests {
-		if output := RelayXConvert(test.input); output != test.expected {
+		if output, err := RelayXConvert(test.input); err == nil && test.expectedError {
+			t.Errorf("%s Failed: expected to throw an error, no error [%s] inputted", t.Name(), test.input)
+		} else if err != nil && !test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted, received error [%s]", t.Name(), test.input, err.Error())
+		} else if err != nil && !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s Failed: [%s] inputted, expected [%v] received: [%v]", t.Name(), test.input, ErrInvalidInput, err)
+		} else if output != test.expected {
 			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.input, test.expected, output)
 		}
 	}
 }
 
-// ExampleHandCashConvert example using RelayXConvert()
+// ExampleRelayXConvert example using RelayXConvert()
 func ExampleRelayXConvert() {
-	paymail := RelayXConvert("1mr-z")
+	paymail, _ := RelayXConvert("1mr-z")
 	fmt.Println(paymail)
 	// Output:mr-z@relayx.io
 }
 
-// BenchmarkHandCashConvert benchmarks the RelayXConvert method
+// BenchmarkRelayXConvert benchmarks the RelayXConvert method
 func BenchmarkRelayXConvert(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_ = RelayXConvert("1mr-z")
+		_, _ = RelayXConvert("1mr-z")
 	}
 }
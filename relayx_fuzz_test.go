@@ -0,0 +1,47 @@
+//go:build go1.18
+// +build go1.18
+
+package polynym
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// FuzzRelayXConvert will fuzz the RelayXConvert() method
+func FuzzRelayXConvert(f *testing.F) {
+	for _, seed := range []string{
+		"1mrz", "1MrZ", "1user1", "11", "1", "", "mrz", "1mr z", "1mr$z", "1mrž", "1@relayx.io",
+		"1abcdefghijklmnopqrstuvwx", "16ZqP5Tb22KJuvSAbjNkoiZs13mmRmexZA", "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, handle string) {
+		paymail, err := RelayXConvert(handle)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("expected [%v] for [%q], received: [%v]", ErrInvalidInput, handle, err)
+			} else if len(paymail) > 0 {
+				t.Fatalf("expected no paymail for [%q], received: [%s]", handle, paymail)
+			}
+			return
+		}
+
+		// Only the leading prefix is removed and the result is a valid paymail
+		if expected := strings.ToLower(handle[1:]) + "@relayx.io"; paymail != expected {
+			t.Fatalf("expected [%s] for [%q], received: [%s]", expected, handle, paymail)
+		} else if !isValidPaymail(paymail) {
+			t.Fatalf("invalid paymail [%s] for [%q]", paymail, handle)
+		} else if len(handle) > relayXMaxLength || IsValidAddress(handle) {
+			t.Fatalf("expected an error for [%q]", handle)
+		}
+
+		// Classify agrees with the conversion
+		if idType, normalized, classifyErr := Classify(handle); classifyErr != nil {
+			t.Fatalf("expected no error for [%q], received: [%v]", handle, classifyErr)
+		} else if idType != IdentifierRelayX || normalized != paymail {
+			t.Fatalf("expected [%s] [%s] for [%q], received: [%s] [%s]", IdentifierRelayX, paymail, handle, idType, normalized)
+		}
+	})
+}
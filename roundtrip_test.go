@@ -0,0 +1,214 @@
+package polynym_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mrz1836/go-polynym"
+	"github.com/mrz1836/go-polynym/server"
+)
+
+// The server package imports polynym, so the round trip between them is tested from package polynym_test
+
+const (
+	// roundTripPubKey is a compressed secp256k1 public key (the generator point)
+	roundTripPubKey = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+	// roundTripScript is a P2PKH output script for the roundTripAddress
+	roundTripScript = "76a9140102030405060708090a0b0c0d0e0f101112131488ac"
+
+	// roundTripAddress is the address for the roundTripScript
+	roundTripAddress = "16L5yRNPTuciSgXGHqYwn9N6NeoKqopAu"
+)
+
+// roundTripStore is a server.AccountStore with one account (mrz@example.com)
+type roundTripStore struct {
+	mu           sync.Mutex
+	transactions []*polynym.P2PTransaction
+}
+
+// GetAccount returns the mrz@example.com account (and the nokey@example.com account without a public key)
+func (r *roundTripStore) GetAccount(_ context.Context, alias, domain string) (*server.Account, error) {
+	if alias == "nokey" && domain == "example.com" {
+		return &server.Account{Alias: alias, Domain: domain, Name: "No Key"}, nil
+	} else if alias != "mrz" || domain != "example.com" {
+		return nil, server.ErrAccountNotFound
+	}
+	return &server.Account{Alias: alias, Avatar: "https://example.com/mrz.png", Domain: domain, Name: "MrZ", PubKey: roundTripPubKey}, nil
+}
+
+// GetPaymentDestination returns one output with the roundTripScript
+func (r *roundTripStore) GetPaymentDestination(_ context.Context, _ *server.Account, satoshis uint64) (*polynym.PaymentDestination, error) {
+	return &polynym.PaymentDestination{
+		Outputs:   []*polynym.PaymentOutput{{Satoshis: satoshis, Script: roundTripScript}},
+		Reference: "ref-" + strconv.FormatUint(satoshis, 10),
+	}, nil
+}
+
+// ReceiveTransaction records the transaction (rejects the "00" transaction)
+func (r *roundTripStore) ReceiveTransaction(_ context.Context, _ *server.Account,
+	transaction *polynym.P2PTransaction) (*polynym.P2PTransactionResponse, error) {
+	if transaction.Hex == "00" {
+		return nil, fmt.Errorf("%w: transaction has no inputs", polynym.ErrTransactionRejected)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transactions = append(r.transactions, transaction)
+	return &polynym.P2PTransactionResponse{Note: "thanks", TxID: "txid-" + transaction.Reference}, nil
+}
+
+// roundTripDNS is a DNS resolver that returns the SRV record of the test server
+type roundTripDNS struct {
+	host string
+	port uint16
+}
+
+// LookupSRV returns the SRV record of the test server
+func (r *roundTripDNS) LookupSRV(_ context.Context, _, _, _ string) (string, []*net.SRV, error) {
+	return "", []*net.SRV{{Target: r.host + ".", Port: r.port}}, nil
+}
+
+// newRoundTripServer starts a TLS paymail server for the store and returns a client that resolves every domain to it
+//
+// The client uses the default options (and the sender handle, if set)
+func newRoundTripServer(t *testing.T, store server.AccountStore, options *server.Options, senderHandle string) *polynym.Client {
+	testServer := httptest.NewTLSServer(server.NewHandler(store, options))
+	t.Cleanup(testServer.Close)
+
+	host, port, _ := net.SplitHostPort(testServer.Listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	clientOptions := polynym.ClientDefaultOptions()
+	clientOptions.DNSResolver = &roundTripDNS{host: host, port: uint16(portNumber)}
+	clientOptions.PaymailSenderHandle = senderHandle
+	clientOptions.PaymailTrustSRVTarget = true
+	clientOptions.RequestRetryCount = 0
+	client := polynym.NewClient(clientOptions)
+	polynym.SetHTTPClient(client, testServer.Client()) // trusts the test certificate
+	return client
+}
+
+// TestServer_RoundTrip will test the client against the paymail server (server.Handler)
+func TestServer_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	store := new(roundTripStore)
+	client := newRoundTripServer(t, store, &server.Options{Domains: []string{"Example.com"}}, "ops@example.com")
+	ctx := context.Background()
+
+	t.Run("default options", func(t *testing.T) {
+		defaultClient := newRoundTripServer(t, store, &server.Options{Domains: []string{"example.com"}}, "")
+
+		// The address resolution requires a sender handle (the server would reject the request)
+		if _, err := defaultClient.ResolvePaymail(ctx, "mrz@example.com"); !errors.Is(err, polynym.ErrInvalidInput) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), polynym.ErrInvalidInput, err)
+		}
+
+		// The P2P payment destination does not
+		if destination, err := defaultClient.GetPaymentDestination(ctx, "mrz@example.com", 1000); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if !destination.P2P || destination.Outputs[0].Address != roundTripAddress {
+			t.Fatalf("%s Failed: unexpected destination [%v]", t.Name(), destination)
+		}
+	})
+
+	t.Run("address resolution", func(t *testing.T) {
+		if output, err := client.ResolvePaymail(ctx, "MrZ@example.com"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if output.Address != roundTripAddress {
+			t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), roundTripAddress, output.Address)
+		}
+		if _, err := client.ResolvePaymail(ctx, "unknown@example.com"); !errors.Is(err, polynym.ErrNotFound) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), polynym.ErrNotFound, err)
+		}
+		if _, err := client.ResolvePaymail(ctx, "mrz@other.com"); !errors.Is(err, polynym.ErrNotFound) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), polynym.ErrNotFound, err)
+		}
+	})
+
+	t.Run("P2P payment", func(t *testing.T) {
+		destination, err := client.GetPaymentDestination(ctx, "mrz@example.com", 1000)
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if !destination.P2P || destination.Reference != "ref-1000" || len(destination.Outputs) != 1 {
+			t.Fatalf("%s Failed: unexpected destination [%v]", t.Name(), destination)
+		} else if destination.Outputs[0].Address != roundTripAddress || destination.Outputs[0].Satoshis != 1000 {
+			t.Fatalf("%s Failed: unexpected output [%v]", t.Name(), destination.Outputs[0])
+		}
+
+		var response *polynym.P2PTransactionResponse
+		if response, err = client.SendP2PTransaction(ctx, "mrz@example.com", &polynym.P2PTransaction{
+			Hex:       "0100",
+			MetaData:  &polynym.P2PTxMetaData{Note: "hello", Sender: "ops@example.com"},
+			Reference: destination.Reference,
+		}); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if response.TxID != "txid-ref-1000" || response.Note != "thanks" {
+			t.Fatalf("%s Failed: unexpected response [%v]", t.Name(), response)
+		}
+		if _, err := client.GetPublicKey(ctx, "nokey@example.com"); !errors.Is(err, polynym.ErrNotFound) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), polynym.ErrNotFound, err)
+		}
+		store.mu.Lock()
+		if len(store.transactions) != 1 || store.transactions[0].MetaData == nil || store.transactions[0].MetaData.Note != "hello" {
+			t.Errorf("%s Failed: the transaction was not recorded", t.Name())
+		}
+		store.mu.Unlock()
+
+		// Rejected by the store
+		if _, err = client.SendP2PTransaction(ctx, "mrz@example.com", &polynym.P2PTransaction{
+			Hex: "00", Reference: destination.Reference,
+		}); !errors.Is(err, polynym.ErrTransactionRejected) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), polynym.ErrTransactionRejected, err)
+		}
+	})
+
+	t.Run("PKI and verification", func(t *testing.T) {
+		if response, err := client.GetPublicKey(ctx, "mrz@example.com"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if response.PubKey != roundTripPubKey || response.Handle != "mrz@example.com" || response.BsvAlias != "1.0" {
+			t.Fatalf("%s Failed: unexpected response [%v]", t.Name(), response)
+		}
+
+		if response, err := client.VerifyPublicKeyOwner(ctx, "mrz@example.com", strings.ToUpper(roundTripPubKey)); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if !response.Match {
+			t.Fatalf("%s Failed: expected the public key to match", t.Name())
+		}
+
+		otherKey := "03" + roundTripPubKey[2:]
+		if response, err := client.VerifyPublicKeyOwner(ctx, "mrz@example.com", otherKey); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if response.Match || response.PubKey != otherKey {
+			t.Fatalf("%s Failed: expected the public key to not match [%v]", t.Name(), response)
+		}
+	})
+
+	t.Run("public profile", func(t *testing.T) {
+		if profile, err := client.GetPublicProfile(ctx, "mrz@example.com"); err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if profile.Name != "MrZ" || profile.Avatar != "https://example.com/mrz.png" {
+			t.Fatalf("%s Failed: unexpected profile [%v]", t.Name(), profile)
+		}
+	})
+
+	t.Run("inspector finds no spec violations", func(t *testing.T) {
+		inspection, err := client.InspectPaymail(ctx, "mrz@example.com")
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		}
+
+		// The only violation is the SRV target (the test server is on 127.0.0.1)
+		if len(inspection.Violations) != 1 || !strings.HasPrefix(inspection.Violations[0], "SRV target") {
+			t.Fatalf("%s Failed: unexpected violations %v", t.Name(), inspection.Violations)
+		} else if len(inspection.Capabilities) != 7 {
+			t.Fatalf("%s Failed: expected [%d] capabilities, received: [%d]", t.Name(), 7, len(inspection.Capabilities))
+		}
+	})
+}
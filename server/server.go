@@ -0,0 +1,367 @@
+/*
+Package server is a bsvalias (paymail) server for the paymails in an AccountStore
+
+Example:
+
+	// Serve the paymails of your store
+	handler := server.NewHandler(store, &server.Options{Domains: []string{"example.com"}})
+	log.Fatal(http.ListenAndServeTLS(":443", "cert.pem", "key.pem", handler))
+
+The capability discovery (.well-known/bsvalias), address resolution, PKI, public key verification,
+public profile, P2P payment destination and P2P receive transaction endpoints are served, so any
+paymail client (including polynym.Client) can resolve and pay the paymails in the store.
+*/
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mrz1836/go-polynym"
+)
+
+const (
+
+	// bsvAliasVersion is the version of the bsvalias specification
+	bsvAliasVersion = "1.0"
+
+	// defaultPathPrefix is the default path of the bsvalias endpoints
+	defaultPathPrefix = "/api/v1/bsvalias"
+
+	// maxRequestSize is the max size of a request body
+	maxRequestSize = 1 << 20
+
+	// wellKnownPath is the path of the capability discovery
+	wellKnownPath = "/.well-known/bsvalias"
+)
+
+// Paths of the endpoints (after the path prefix)
+const (
+	pathAddress            = "/address/"
+	pathP2PDestination     = "/p2p-payment-destination/"
+	pathPublicProfile      = "/public-profile/"
+	pathPKI                = "/id/"
+	pathReceiveTransaction = "/receive-transaction/"
+	pathVerifyPubKey       = "/verify-pubkey/"
+)
+
+// Options is the configuration for the Handler
+type Options struct {
+	BaseURL    string   `json:"base_url"`    // BaseURL of the endpoints in the capabilities (default: https:// and the request host)
+	Domains    []string `json:"domains"`     // Domains that are served (any domain if empty)
+	PathPrefix string   `json:"path_prefix"` // PathPrefix of the endpoints (default: /api/v1/bsvalias)
+}
+
+// Handler is an http.Handler that serves the bsvalias endpoints for the accounts in the store
+type Handler struct {
+	baseURL    string
+	domains    map[string]bool
+	pathPrefix string
+	store      AccountStore
+}
+
+// errorResponse is the body of an error response
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// addressRequest is the body of the address resolution request (sender information)
+type addressRequest struct {
+	Amount       uint64 `json:"amount"`
+	Dt           string `json:"dt"`
+	Purpose      string `json:"purpose"`
+	SenderHandle string `json:"senderHandle"`
+	SenderName   string `json:"senderName"`
+	Signature    string `json:"signature"`
+}
+
+// p2pDestinationRequest is the body of the P2P payment destination request
+type p2pDestinationRequest struct {
+	Satoshis uint64 `json:"satoshis"`
+}
+
+// NewHandler will create a new bsvalias handler for the accounts in the store
+func NewHandler(store AccountStore, options *Options) *Handler {
+	if options == nil {
+		options = &Options{}
+	}
+	h := &Handler{
+		baseURL:    strings.TrimSuffix(options.BaseURL, "/"),
+		pathPrefix: defaultPathPrefix,
+		store:      store,
+	}
+	if len(options.PathPrefix) > 0 {
+		h.pathPrefix = strings.TrimSuffix("/"+strings.Trim(options.PathPrefix, "/"), "/")
+	}
+	if len(options.Domains) > 0 {
+		h.domains = make(map[string]bool, len(options.Domains))
+		for _, domain := range options.Domains {
+			h.domains[strings.ToLower(domain)] = true
+		}
+	}
+	return h
+}
+
+// ServeHTTP will route the request to the endpoint
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := req.URL.Path
+	if path == wellKnownPath {
+		if h.allowMethod(w, req, http.MethodGet) {
+			h.capabilities(w, req)
+		}
+		return
+	} else if !strings.HasPrefix(path, h.pathPrefix+"/") {
+		respondError(w, http.StatusNotFound, "not-found", "not found")
+		return
+	}
+	path = strings.TrimPrefix(path, h.pathPrefix)
+
+	// Routes are {endpoint}{alias}@{domain.tld} (verify-pubkey also has /{pubkey})
+	var endpoint, method string
+	for _, route := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, pathAddress},
+		{http.MethodPost, pathP2PDestination},
+		{http.MethodGet, pathPKI},
+		{http.MethodGet, pathPublicProfile},
+		{http.MethodPost, pathReceiveTransaction},
+		{http.MethodGet, pathVerifyPubKey},
+	} {
+		if strings.HasPrefix(path, route.path) {
+			endpoint, method = route.path, route.method
+			break
+		}
+	}
+	if len(endpoint) == 0 {
+		respondError(w, http.StatusNotFound, "not-found", "not found")
+		return
+	} else if !h.allowMethod(w, req, method) {
+		return
+	}
+
+	// Get the account
+	paymail, pubKey := strings.TrimPrefix(path, endpoint), ""
+	if endpoint == pathVerifyPubKey {
+		if index := strings.LastIndex(paymail, "/"); index >= 0 {
+			paymail, pubKey = paymail[:index], paymail[index+1:]
+		}
+	}
+	account, ok := h.getAccount(w, req, paymail)
+	if !ok {
+		return
+	}
+
+	switch endpoint {
+	case pathAddress:
+		h.address(w, req, account)
+	case pathP2PDestination:
+		h.p2pDestination(w, req, account)
+	case pathPKI:
+		if len(account.PubKey) == 0 {
+			respondError(w, http.StatusNotFound, "not-found", "public key not found: "+handle(account))
+			return
+		}
+		respondJSON(w, http.StatusOK, &polynym.PKIResponse{
+			BsvAlias: bsvAliasVersion,
+			Handle:   handle(account),
+			PubKey:   account.PubKey,
+		})
+	case pathPublicProfile:
+		respondJSON(w, http.StatusOK, &polynym.PublicProfile{Avatar: account.Avatar, Name: account.Name})
+	case pathReceiveTransaction:
+		h.receiveTransaction(w, req, account)
+	case pathVerifyPubKey:
+		h.verifyPubKey(w, account, pubKey)
+	}
+}
+
+// capabilities will return the capability discovery document
+func (h *Handler) capabilities(w http.ResponseWriter, req *http.Request) {
+	baseURL := h.baseURL
+	if len(baseURL) == 0 {
+		baseURL = "https://" + req.Host
+	}
+	endpoint := func(path, suffix string) string {
+		return baseURL + h.pathPrefix + path + "{alias}@{domain.tld}" + suffix
+	}
+	respondJSON(w, http.StatusOK, &polynym.Capabilities{
+		BsvAlias: bsvAliasVersion,
+		Capabilities: map[string]interface{}{
+			"paymentDestination":              endpoint(pathAddress, ""),
+			"pki":                             endpoint(pathPKI, ""),
+			polynym.BRFCP2PPaymentDestination: endpoint(pathP2PDestination, ""),
+			polynym.BRFCP2PReceiveTransaction: endpoint(pathReceiveTransaction, ""),
+			polynym.BRFCPublicProfile:         endpoint(pathPublicProfile, ""),
+			polynym.BRFCSenderValidation:      false,
+			polynym.BRFCVerifyPublicKeyOwner:  endpoint(pathVerifyPubKey, "/{pubkey}"),
+		},
+	})
+}
+
+// address will return the output script for the basic address resolution
+func (h *Handler) address(w http.ResponseWriter, req *http.Request, account *Account) {
+	body := new(addressRequest)
+	if !decodeRequest(w, req, body) {
+		return
+	} else if len(body.SenderHandle) == 0 || len(body.Dt) == 0 {
+		respondError(w, http.StatusBadRequest, "invalid-request", "senderHandle and dt are required")
+		return
+	}
+
+	destination, ok := h.getPaymentDestination(w, req, account, body.Amount)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"output": destination.Outputs[0].Script})
+}
+
+// p2pDestination will return the outputs and reference for the P2P payment destination
+func (h *Handler) p2pDestination(w http.ResponseWriter, req *http.Request, account *Account) {
+	body := new(p2pDestinationRequest)
+	if !decodeRequest(w, req, body) {
+		return
+	} else if body.Satoshis == 0 {
+		respondError(w, http.StatusBadRequest, "invalid-satoshis", "satoshis must be greater than zero")
+		return
+	}
+
+	destination, ok := h.getPaymentDestination(w, req, account, body.Satoshis)
+	if !ok {
+		return
+	} else if len(destination.Reference) == 0 {
+		respondError(w, http.StatusInternalServerError, "internal-error", "missing reference for the payment destination")
+		return
+	}
+	respondJSON(w, http.StatusOK, destination)
+}
+
+// receiveTransaction will record the P2P transaction and return the txid
+func (h *Handler) receiveTransaction(w http.ResponseWriter, req *http.Request, account *Account) {
+	transaction := new(polynym.P2PTransaction)
+	if !decodeRequest(w, req, transaction) {
+		return
+	} else if _, err := hex.DecodeString(transaction.Hex); err != nil || len(transaction.Hex) == 0 {
+		respondError(w, http.StatusBadRequest, "invalid-hex", "hex must be a raw transaction")
+		return
+	} else if len(transaction.Reference) == 0 {
+		respondError(w, http.StatusBadRequest, "invalid-reference", "reference is required")
+		return
+	}
+
+	response, err := h.store.ReceiveTransaction(req.Context(), account, transaction)
+	if err != nil {
+		storeError(w, err)
+		return
+	} else if response == nil || len(response.TxID) == 0 {
+		respondError(w, http.StatusInternalServerError, "internal-error", "missing txid for the transaction")
+		return
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// verifyPubKey will check if the public key belongs to the account
+func (h *Handler) verifyPubKey(w http.ResponseWriter, account *Account, pubKey string) {
+	if err := polynym.ValidatePublicKey(pubKey); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid-pubkey", "pubkey must be a compressed public key")
+		return
+	}
+	respondJSON(w, http.StatusOK, &polynym.VerifyPubKeyResponse{
+		BsvAlias: bsvAliasVersion,
+		Handle:   handle(account),
+		Match:    len(account.PubKey) > 0 && strings.EqualFold(account.PubKey, pubKey),
+		PubKey:   pubKey,
+	})
+}
+
+// getAccount will get the account for the paymail (writes the error response if not found)
+func (h *Handler) getAccount(w http.ResponseWriter, req *http.Request, paymail string) (*Account, bool) {
+	parts := strings.Split(strings.ToLower(paymail), "@")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		respondError(w, http.StatusBadRequest, "invalid-paymail", "invalid paymail: "+paymail)
+		return nil, false
+	} else if h.domains != nil && !h.domains[parts[1]] {
+		respondError(w, http.StatusNotFound, "not-found", "paymail not found: "+paymail)
+		return nil, false
+	}
+
+	account, err := h.store.GetAccount(req.Context(), parts[0], parts[1])
+	if err == nil && account == nil {
+		err = ErrAccountNotFound
+	}
+	if err != nil {
+		storeError(w, err)
+		return nil, false
+	}
+	return account, true
+}
+
+// getPaymentDestination will get the outputs from the store (writes the error response if it fails)
+func (h *Handler) getPaymentDestination(w http.ResponseWriter, req *http.Request, account *Account,
+	satoshis uint64) (*polynym.PaymentDestination, bool) {
+
+	destination, err := h.store.GetPaymentDestination(req.Context(), account, satoshis)
+	if err != nil {
+		storeError(w, err)
+		return nil, false
+	} else if destination == nil || len(destination.Outputs) == 0 || destination.Outputs[0] == nil {
+		respondError(w, http.StatusInternalServerError, "internal-error", "missing outputs for the payment destination")
+		return nil, false
+	}
+	return destination, true
+}
+
+// allowMethod returns true if the request uses the method (writes the error response if not)
+func (h *Handler) allowMethod(w http.ResponseWriter, req *http.Request, method string) bool {
+	if req.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	respondError(w, http.StatusMethodNotAllowed, "method-not-allowed", "method not allowed")
+	return false
+}
+
+// decodeRequest will decode the JSON body of the request (writes the error response if it fails)
+func decodeRequest(w http.ResponseWriter, req *http.Request, value interface{}) bool {
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxRequestSize)).Decode(value); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid-body", "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// storeError will write the error response for an error from the store
+func storeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrAccountNotFound):
+		respondError(w, http.StatusNotFound, "not-found", "paymail not found")
+	case errors.Is(err, polynym.ErrTransactionRejected):
+		respondError(w, http.StatusBadRequest, "rejected", err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, "internal-error", "internal error")
+	}
+}
+
+// handle returns the paymail of the account
+func handle(account *Account) string {
+	return fmt.Sprintf("%s@%s", account.Alias, account.Domain)
+}
+
+// respondError will write the error as JSON with the status code
+func respondError(w http.ResponseWriter, status int, code, message string) {
+	respondJSON(w, status, &errorResponse{Code: code, Message: message})
+}
+
+// respondJSON will write the value as JSON with the status code
+func respondJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mrz1836/go-polynym"
+)
+
+const (
+	// testPubKey is a compressed secp256k1 public key (the generator point)
+	testPubKey = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+	// testScript is a P2PKH output script for the testAddress
+	testScript = "76a9140102030405060708090a0b0c0d0e0f101112131488ac"
+
+	// testAddress is the address for the testScript
+	testAddress = "16L5yRNPTuciSgXGHqYwn9N6NeoKqopAu"
+)
+
+// mockStore is an AccountStore with one account (mrz@example.com)
+type mockStore struct {
+	err          error
+	mu           sync.Mutex
+	transactions []*polynym.P2PTransaction
+}
+
+// GetAccount returns the mrz@example.com account (and the nokey@example.com account without a public key)
+func (m *mockStore) GetAccount(_ context.Context, alias, domain string) (*Account, error) {
+	if m.err != nil {
+		return nil, m.err
+	} else if alias == "nokey" && domain == "example.com" {
+		return &Account{Alias: alias, Domain: domain, Name: "No Key"}, nil
+	} else if alias != "mrz" || domain != "example.com" {
+		return nil, ErrAccountNotFound
+	}
+	return &Account{Alias: alias, Avatar: "https://example.com/mrz.png", Domain: domain, Name: "MrZ", PubKey: testPubKey}, nil
+}
+
+// GetPaymentDestination returns one output with the testScript
+func (m *mockStore) GetPaymentDestination(_ context.Context, _ *Account, satoshis uint64) (*polynym.PaymentDestination, error) {
+	return &polynym.PaymentDestination{
+		Outputs:   []*polynym.PaymentOutput{{Satoshis: satoshis, Script: testScript}},
+		Reference: "ref-" + strconv.FormatUint(satoshis, 10),
+	}, nil
+}
+
+// ReceiveTransaction records the transaction (rejects the "00" transaction)
+func (m *mockStore) ReceiveTransaction(_ context.Context, _ *Account,
+	transaction *polynym.P2PTransaction) (*polynym.P2PTransactionResponse, error) {
+	if transaction.Hex == "00" {
+		return nil, fmt.Errorf("%w: transaction has no inputs", polynym.ErrTransactionRejected)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transactions = append(m.transactions, transaction)
+	return &polynym.P2PTransactionResponse{Note: "thanks", TxID: "txid-" + transaction.Reference}, nil
+}
+
+// TestHandler_ServeHTTP will test the requests to the Handler
+func TestHandler_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	handler := NewHandler(&mockStore{}, &Options{BaseURL: "https://paymail.example.com/", PathPrefix: "/bsvalias/"})
+	prefix := "/bsvalias"
+
+	// Create the list of tests
+	var tests = []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"capabilities", http.MethodGet, wellKnownPath, "", http.StatusOK, `"pki":"https://paymail.example.com/bsvalias/id/{alias}@{domain.tld}"`},
+		{"capabilities method", http.MethodPost, wellKnownPath, "", http.StatusMethodNotAllowed, "method-not-allowed"},
+		{"unknown path", http.MethodGet, "/unknown", "", http.StatusNotFound, "not-found"},
+		{"unknown endpoint", http.MethodGet, prefix + "/unknown/mrz@example.com", "", http.StatusNotFound, "not-found"},
+		{"default prefix", http.MethodGet, defaultPathPrefix + "/id/mrz@example.com", "", http.StatusNotFound, "not-found"},
+		{"wrong method", http.MethodGet, prefix + "/address/mrz@example.com", "", http.StatusMethodNotAllowed, "method-not-allowed"},
+		{"invalid paymail", http.MethodGet, prefix + "/id/mrz", "", http.StatusBadRequest, "invalid-paymail"},
+		{"unknown paymail", http.MethodGet, prefix + "/id/unknown@example.com", "", http.StatusNotFound, "not-found"},
+		{"pki", http.MethodGet, prefix + "/id/MrZ@Example.com", "", http.StatusOK, `"handle":"mrz@example.com","pubkey":"` + testPubKey},
+		{"pki without a public key", http.MethodGet, prefix + "/id/nokey@example.com", "", http.StatusNotFound, "not-found"},
+		{"profile", http.MethodGet, prefix + "/public-profile/mrz@example.com", "", http.StatusOK, `"name":"MrZ"`},
+		{"verify", http.MethodGet, prefix + "/verify-pubkey/mrz@example.com/" + testPubKey, "", http.StatusOK, `"match":true`},
+		{"verify invalid key", http.MethodGet, prefix + "/verify-pubkey/mrz@example.com/1234", "", http.StatusBadRequest, "invalid-pubkey"},
+		{"verify missing key", http.MethodGet, prefix + "/verify-pubkey/mrz@example.com", "", http.StatusBadRequest, "invalid-pubkey"},
+		{"address", http.MethodPost, prefix + "/address/mrz@example.com", `{"senderHandle":"ops@example.com","dt":"2020-01-01T00:00:00Z"}`, http.StatusOK, `"output":"` + testScript},
+		{"address invalid body", http.MethodPost, prefix + "/address/mrz@example.com", "not-json", http.StatusBadRequest, "invalid-body"},
+		{"address missing sender", http.MethodPost, prefix + "/address/mrz@example.com", `{"dt":"2020-01-01T00:00:00Z"}`, http.StatusBadRequest, "invalid-request"},
+		{"p2p destination", http.MethodPost, prefix + "/p2p-payment-destination/mrz@example.com", `{"satoshis":10}`, http.StatusOK, `"reference":"ref-10"`},
+		{"p2p destination no satoshis", http.MethodPost, prefix + "/p2p-payment-destination/mrz@example.com", `{}`, http.StatusBadRequest, "invalid-satoshis"},
+		{"receive", http.MethodPost, prefix + "/receive-transaction/mrz@example.com", `{"hex":"0100","reference":"ref"}`, http.StatusOK, `"txid":"txid-ref"`},
+		{"receive invalid hex", http.MethodPost, prefix + "/receive-transaction/mrz@example.com", `{"hex":"zz","reference":"ref"}`, http.StatusBadRequest, "invalid-hex"},
+		{"receive missing reference", http.MethodPost, prefix + "/receive-transaction/mrz@example.com", `{"hex":"0100"}`, http.StatusBadRequest, "invalid-reference"},
+		{"receive rejected", http.MethodPost, prefix + "/receive-transaction/mrz@example.com", `{"hex":"00","reference":"ref"}`, http.StatusBadRequest, "transaction has no inputs"},
+	}
+
+	// Test all
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, bytes.NewBufferString(test.body)))
+		if recorder.Code != test.expectedStatus {
+			t.Errorf("%s Failed: [%s] expected [%d] received: [%d] [%s]", t.Name(), test.name, test.expectedStatus, recorder.Code, recorder.Body.String())
+		} else if recorder.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s Failed: [%s] unexpected content type [%s]", t.Name(), test.name, recorder.Header().Get("Content-Type"))
+		} else if !strings.Contains(recorder.Body.String(), test.expectedBody) {
+			t.Errorf("%s Failed: [%s] expected [%s] in: [%s]", t.Name(), test.name, test.expectedBody, recorder.Body.String())
+		}
+	}
+}
+
+// TestHandler_StoreErrors will test the errors from the store
+func TestHandler_StoreErrors(t *testing.T) {
+	t.Parallel()
+
+	handler := NewHandler(&mockStore{err: errors.New("database is down")}, nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, defaultPathPrefix+"/id/mrz@example.com", nil))
+
+	response := new(errorResponse)
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("%s Failed: expected [%d] received: [%d]", t.Name(), http.StatusInternalServerError, recorder.Code)
+	} else if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	} else if response.Code != "internal-error" || strings.Contains(response.Message, "database") {
+		t.Fatalf("%s Failed: the internal error should not be exposed [%v]", t.Name(), response)
+	}
+
+	// The capabilities use the request host
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "https://paymail.example.com"+wellKnownPath, nil))
+	if !strings.Contains(recorder.Body.String(), `"https://paymail.example.com/api/v1/bsvalias/address/{alias}@{domain.tld}"`) {
+		t.Fatalf("%s Failed: unexpected capabilities [%s]", t.Name(), recorder.Body.String())
+	}
+}
+
+// ExampleNewHandler example using NewHandler()
+func ExampleNewHandler() {
+	handler := NewHandler(&mockStore{}, &Options{BaseURL: "https://example.com"})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/bsvalias/public-profile/mrz@example.com", nil))
+	fmt.Print(recorder.Body.String())
+	// Output:{"avatar":"https://example.com/mrz.png","name":"MrZ"}
+}
+
+// BenchmarkHandler_ServeHTTP benchmarks the Handler.ServeHTTP method
+func BenchmarkHandler_ServeHTTP(b *testing.B) {
+	handler := NewHandler(&mockStore{}, nil)
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/bsvalias/id/mrz@example.com", nil))
+	}
+}
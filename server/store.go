@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mrz1836/go-polynym"
+)
+
+// ErrAccountNotFound is returned by the AccountStore when the paymail does not exist (404)
+var ErrAccountNotFound = errors.New("account not found")
+
+// Account is a paymail that is served by the Handler
+type Account struct {
+	Alias  string `json:"alias"`  // Alias is the part before the @ (mrz)
+	Avatar string `json:"avatar"` // Avatar is the URL of the avatar image (public profile)
+	Domain string `json:"domain"` // Domain is the part after the @ (example.com)
+	Name   string `json:"name"`   // Name is the display name (public profile)
+	PubKey string `json:"pubkey"` // PubKey is the compressed public key (hex) for the PKI (404 if empty)
+}
+
+// AccountStore is the interface for the accounts served by the Handler, implement it with your own storage
+//
+// Return ErrAccountNotFound for unknown paymails and polynym.ErrTransactionRejected (wrapped with
+// a reason) for transactions that are not accepted, any other error is an internal error (500)
+type AccountStore interface {
+
+	// GetAccount returns the account for the alias and domain (lowercase)
+	GetAccount(ctx context.Context, alias, domain string) (*Account, error)
+
+	// GetPaymentDestination returns the outputs (and the reference for P2P) for a payment
+	//
+	// The satoshis are 0 when the sender did not provide an amount (basic address resolution)
+	GetPaymentDestination(ctx context.Context, account *Account, satoshis uint64) (*polynym.PaymentDestination, error)
+
+	// ReceiveTransaction records the P2P transaction sent to the account and returns the txid
+	ReceiveTransaction(ctx context.Context, account *Account, transaction *polynym.P2PTransaction) (*polynym.P2PTransactionResponse, error)
+}
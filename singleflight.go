@@ -0,0 +1,116 @@
+package polynym
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup collapses concurrent lookups of the same canonical identifier into a single request
+type flightGroup struct {
+	calls map[string]*flightCall // in-flight calls by key
+	sync.Mutex
+}
+
+// flightCall is a single in-flight lookup shared by all the waiters
+type flightCall struct {
+	cancel   context.CancelFunc  // cancels the lookup when all the waiters are gone
+	done     chan struct{}       // closed when the lookup is complete
+	err      error               // the error from the lookup
+	response *GetAddressResponse // the response from the lookup
+	waiters  int                 // number of callers waiting on the lookup
+}
+
+// flightFunc is the lookup that is shared between the waiters
+type flightFunc func(ctx context.Context) (*GetAddressResponse, error)
+
+// do will run the lookup for the key (or join the one in-flight) and wait for the result or the context
+//
+// The lookup uses its own context, which is only canceled once every waiter has gone away (it keeps the
+// values of the first caller's context, like the tracing span). Each waiter receives its own copy of the response.
+func (g *flightGroup) do(ctx context.Context, key string, fn flightFunc) (*GetAddressResponse, error) {
+
+	// Do not start (or join) a lookup if the caller has already given up
+	if err := ctx.Err(); err != nil {
+		return nil, newResolveError(ErrTransportFailure, nil, "", err)
+	}
+
+	g.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	call, ok := g.calls[key]
+	if !ok {
+		flightCtx, cancel := context.WithCancel(detachedContext{ctx})
+		call = &flightCall{cancel: cancel, done: make(chan struct{})}
+		g.calls[key] = call
+		go g.run(flightCtx, key, call, fn)
+	}
+	call.waiters++
+	g.Unlock()
+
+	// Wait for the lookup or the caller to give up
+	select {
+	case <-call.done:
+		return copyResponse(call.response), copyError(call.err)
+	case <-ctx.Done():
+		g.leave(key, call)
+		return nil, newResolveError(ErrTransportFailure, nil, "", ctx.Err())
+	}
+}
+
+// run will run the lookup and release the waiters (only removing the call if it was not replaced)
+func (g *flightGroup) run(ctx context.Context, key string, call *flightCall, fn flightFunc) {
+	call.response, call.err = fn(ctx)
+	call.cancel()
+	g.Lock()
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	g.Unlock()
+	close(call.done)
+}
+
+// leave will remove a waiter from the call (canceling the lookup if it was the last one)
+//
+// A canceled lookup is removed from the group right away, so the next caller starts a new one
+func (g *flightGroup) leave(key string, call *flightCall) {
+	g.Lock()
+	defer g.Unlock()
+	if call.waiters--; call.waiters == 0 {
+		call.cancel()
+		if g.calls[key] == call {
+			delete(g.calls, key)
+		}
+	}
+}
+
+// copyResponse returns a copy of the response (and the last request) so waiters do not share it
+func copyResponse(response *GetAddressResponse) *GetAddressResponse {
+	if response == nil {
+		return nil
+	}
+	responseCopy := *response
+	responseCopy.LastRequest = copyLastRequest(response.LastRequest)
+	return &responseCopy
+}
+
+// copyError returns a copy of the error (if it is a *ResolveError) so waiters do not share it
+func copyError(err error) error {
+	resolveErr, ok := err.(*ResolveError)
+	if !ok {
+		return err
+	}
+	errCopy := *resolveErr
+	errCopy.LastRequest = copyLastRequest(resolveErr.LastRequest)
+	return &errCopy
+}
+
+// copyLastRequest returns a copy of the last request (and its attempts)
+func copyLastRequest(lastRequest *LastRequest) *LastRequest {
+	if lastRequest == nil {
+		return nil
+	}
+	lastRequestCopy := *lastRequest
+	lastRequestCopy.AttemptTraces = append([]*AttemptTrace(nil), lastRequest.AttemptTraces...)
+	return &lastRequestCopy
+}
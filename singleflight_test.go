@@ -0,0 +1,196 @@
+package polynym
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor will wait until the condition is true (or fail after a second)
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s Failed: timed out waiting for the condition", t.Name())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestGetAddress_Singleflight will test collapsing concurrent lookups into a single request
+func TestGetAddress_Singleflight(t *testing.T) {
+	t.Parallel()
+
+	t.Run("concurrent lookups share one request", func(t *testing.T) {
+		mock := &mockHTTPGate{open: make(chan struct{})}
+		client := &Client{httpClient: mock, UserAgent: defaultUserAgent}
+
+		var wg sync.WaitGroup
+		responses := make([]*GetAddressResponse, 10)
+		errs := make([]error, 10)
+		for i := range responses {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				input := "$MrZ"
+				if i%2 == 0 {
+					input = "mrz@handcash.io"
+				}
+				responses[i], errs[i] = client.GetAddressWithContext(context.Background(), input)
+			}(i)
+		}
+
+		waitFor(t, func() bool { return atomic.LoadInt64(&mock.requests) > 0 })
+		time.Sleep(10 * time.Millisecond)
+		close(mock.open)
+		wg.Wait()
+
+		for i := range responses {
+			if errs[i] != nil {
+				t.Fatalf("%s Failed: error [%s]", t.Name(), errs[i].Error())
+			} else if responses[i].Address != "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h" {
+				t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "19gKzz8XmFDyrpk4qFobG7qKoqybe78v9h", responses[i].Address)
+			} else if i > 0 && responses[i] == responses[0] {
+				t.Fatalf("%s Failed: expected each caller to have its own response", t.Name())
+			}
+		}
+		if requests := atomic.LoadInt64(&mock.requests); requests != 1 {
+			t.Fatalf("%s Failed: expected [%d] requests, received: [%d]", t.Name(), 1, requests)
+		}
+	})
+
+	t.Run("canceled caller does not cancel the others", func(t *testing.T) {
+		mock := &mockHTTPGate{open: make(chan struct{})}
+		client := &Client{httpClient: mock, UserAgent: defaultUserAgent}
+
+		var waiterErr error
+		var waiterResponse *GetAddressResponse
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			waiterResponse, waiterErr = client.GetAddressWithContext(context.Background(), "1mrz")
+		}()
+		waitFor(t, func() bool { return atomic.LoadInt64(&mock.requests) > 0 })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			cancel()
+		}()
+		response, err := client.GetAddressWithContext(ctx, "1mrz")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), context.Canceled, err)
+		} else if response == nil || response.LastRequest == nil {
+			t.Fatalf("%s Failed: expected a response with the last request", t.Name())
+		}
+
+		close(mock.open)
+		<-done
+		if waiterErr != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), waiterErr.Error())
+		} else if waiterResponse.Address != "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa" {
+			t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa", waiterResponse.Address)
+		} else if canceled := atomic.LoadInt64(&mock.canceled); canceled != 0 {
+			t.Fatalf("%s Failed: expected the request not to be canceled", t.Name())
+		}
+	})
+
+	t.Run("last caller leaving cancels the request", func(t *testing.T) {
+		mock := &mockHTTPGate{open: make(chan struct{})}
+		client := &Client{httpClient: mock, UserAgent: defaultUserAgent}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := client.GetAddressWithContext(ctx, "1mrz"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), context.DeadlineExceeded, err)
+		}
+		waitFor(t, func() bool { return atomic.LoadInt64(&mock.canceled) == 1 })
+	})
+	t.Run("caller after a canceled lookup starts a new one", func(t *testing.T) {
+		var group flightGroup
+		var calls int64
+		release := make(chan struct{})
+		fn := func(ctx context.Context) (*GetAddressResponse, error) {
+			if atomic.AddInt64(&calls, 1) == 1 {
+				select { // the first lookup finishes after its caller has gone away
+				case <-release:
+				case <-time.After(time.Second):
+				}
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, newResolveError(ErrTransportFailure, nil, "", err)
+			}
+			return &GetAddressResponse{Address: "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa"}, nil
+		}
+		defer close(release)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			waitFor(t, func() bool { return atomic.LoadInt64(&calls) == 1 })
+			cancel()
+		}()
+		if _, err := group.do(ctx, "1mrz", fn); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s Failed: expected [%v] received: [%v]", t.Name(), context.Canceled, err)
+		}
+
+		// The first lookup is still running (canceled), the new caller should not join it
+		response, err := group.do(context.Background(), "1mrz", fn)
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if response.Address != "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa" {
+			t.Fatalf("%s Failed: expected [%s] received: [%s]", t.Name(), "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa", response.Address)
+		} else if lookups := atomic.LoadInt64(&calls); lookups != 2 {
+			t.Fatalf("%s Failed: expected [%d] lookups, received: [%d]", t.Name(), 2, lookups)
+		}
+	})
+
+	t.Run("finished canceled lookup does not remove the new one", func(t *testing.T) {
+		var group flightGroup
+		var calls int64
+		first, second := make(chan struct{}), make(chan struct{})
+		fn := func(ctx context.Context) (*GetAddressResponse, error) {
+			if atomic.AddInt64(&calls, 1) == 1 {
+				<-first
+			} else {
+				<-second
+			}
+			return &GetAddressResponse{Address: "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa"}, nil
+		}
+		current := func() *flightCall {
+			group.Lock()
+			defer group.Unlock()
+			return group.calls["1mrz"]
+		}
+
+		// The first caller gives up, a second caller starts a new lookup
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			waitFor(t, func() bool { return atomic.LoadInt64(&calls) == 1 })
+			cancel()
+		}()
+		_, _ = group.do(ctx, "1mrz", fn)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = group.do(context.Background(), "1mrz", fn)
+		}()
+		waitFor(t, func() bool { return atomic.LoadInt64(&calls) == 2 })
+		call := current()
+
+		// Finishing the first lookup should not remove the new one
+		close(first)
+		time.Sleep(5 * time.Millisecond)
+		if current() != call {
+			t.Fatalf("%s Failed: the finished lookup removed the new one", t.Name())
+		}
+		close(second)
+		<-done
+		if current() != nil {
+			t.Fatalf("%s Failed: expected the lookup to be removed", t.Name())
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package polynym
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Span names used with the Tracer
+const (
+	SpanRequest = "polynym.request" // A single HTTP request (every attempt, including retries)
+	SpanResolve = "polynym.resolve" // A lookup (GetAddress, GetAddresses, etc)
+)
+
+// Timings are the phases of a single HTTP request (measured with net/http/httptrace)
+//
+// DNS, Connect and TLSHandshake are zero when a connection was reused. TTFB and Total are
+// measured from the start of the attempt.
+type Timings struct {
+	Connect      time.Duration `json:"connect"`       // Connect is the time to open the TCP connection
+	DNS          time.Duration `json:"dns"`           // DNS is the time of the DNS lookup
+	TLSHandshake time.Duration `json:"tls_handshake"` // TLSHandshake is the time of the TLS handshake
+	TTFB         time.Duration `json:"ttfb"`          // TTFB is the time to the first byte of the response
+	Total        time.Duration `json:"total"`         // Total is the time until the response headers (or the error)
+}
+
+// AttemptTrace is a single attempt of a request (the first request or a retry)
+type AttemptTrace struct {
+	Attempt       int           `json:"attempt"`         // Attempt is the number of the attempt (1 is the first request, 2+ are retries)
+	BackOff       time.Duration `json:"back_off"`        // BackOff is the time waited before the attempt (0 for the first)
+	Error         string        `json:"error,omitempty"` // Error is the transport error (if any)
+	RemoteAddress string        `json:"remote_address"`  // RemoteAddress is the address of the connection (if any)
+	ReusedConn    bool          `json:"reused_conn"`     // ReusedConn is true if an idle connection was reused
+	StartedAt     time.Time     `json:"started_at"`      // StartedAt is when the attempt was sent
+	StatusCode    int           `json:"status_code"`     // StatusCode is the status of the response (0 if no response)
+	Timings       Timings       `json:"timings"`         // Timings are the phases of the attempt
+}
+
+// Tracer is the interface for tracing, set it on the Options to bridge into a tracing system
+//
+// A SpanResolve span is started for every lookup and a SpanRequest span (a child of the lookup,
+// if any) for every HTTP attempt. The context returned by StartSpan is used for the work inside
+// the span, so it can carry the parent span.
+//
+// Attributes of the SpanResolve span: polynym.identifier_type, polynym.outcome, polynym.cache_hit,
+// polynym.attempts and http.status_code. Attributes of the SpanRequest span: http.method, http.host,
+// http.status_code, polynym.upstream, polynym.attempt and the timings (polynym.dns, polynym.connect,
+// polynym.tls_handshake, polynym.ttfb as a time.Duration).
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by the Tracer, End is called once with the error of the work (nil on success)
+type Span interface {
+	End(err error)
+	SetAttribute(key string, value interface{})
+}
+
+// noopSpan is used when no Tracer is set
+type noopSpan struct{}
+
+// End does nothing
+func (noopSpan) End(error) {}
+
+// SetAttribute does nothing
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+// startSpan will start a span with the tracer (if set)
+func (c *Client) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return c.tracer.StartSpan(ctx, name)
+}
+
+// endResolveSpan will set the attributes of the lookup and end the span
+func endResolveSpan(span Span, idType IdentifierType, response *GetAddressResponse, err error) {
+	span.SetAttribute("polynym.identifier_type", idType.String())
+	span.SetAttribute("polynym.outcome", string(OutcomeOf(err)))
+	if response != nil {
+		span.SetAttribute("polynym.cache_hit", response.CacheHit)
+		if response.LastRequest != nil {
+			span.SetAttribute("polynym.attempts", response.LastRequest.Attempts)
+			span.SetAttribute("http.status_code", response.LastRequest.StatusCode)
+		}
+	}
+	span.End(err)
+}
+
+// endRequestSpan will set the attributes of the attempt and end the span
+func endRequestSpan(span Span, event *RequestEvent) {
+	span.SetAttribute("http.method", event.Method)
+	span.SetAttribute("http.host", event.Host)
+	span.SetAttribute("http.status_code", event.StatusCode)
+	span.SetAttribute("polynym.upstream", event.Upstream)
+	span.SetAttribute("polynym.attempt", event.Attempt)
+	span.SetAttribute("polynym.dns", event.Timings.DNS)
+	span.SetAttribute("polynym.connect", event.Timings.Connect)
+	span.SetAttribute("polynym.tls_handshake", event.Timings.TLSHandshake)
+	span.SetAttribute("polynym.ttfb", event.Timings.TTFB)
+	span.End(event.Err)
+}
+
+// attemptTracer measures the phases of an attempt with httptrace
+//
+// The hooks can be called from the dialing goroutines, so the fields are guarded by the mutex
+type attemptTracer struct {
+	connectStart time.Time
+	dnsStart     time.Time
+	mu           sync.Mutex
+	start        time.Time
+	tlsStart     time.Time
+	trace        *AttemptTrace
+}
+
+// newAttemptTracer will start measuring the attempt
+func newAttemptTracer(attempt int, backOff time.Duration) *attemptTracer {
+	now := time.Now()
+	return &attemptTracer{start: now, trace: &AttemptTrace{Attempt: attempt, BackOff: backOff, StartedAt: now}}
+}
+
+// withContext returns the context with the httptrace hooks
+func (a *attemptTracer) withContext(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		ConnectDone: func(_, _ string, _ error) {
+			a.mu.Lock()
+			a.trace.Timings.Connect = time.Since(a.connectStart)
+			a.mu.Unlock()
+		},
+		ConnectStart: func(_, _ string) {
+			a.mu.Lock()
+			if a.connectStart.IsZero() {
+				a.connectStart = time.Now()
+			}
+			a.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			a.mu.Lock()
+			a.trace.Timings.DNS = time.Since(a.dnsStart)
+			a.mu.Unlock()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			a.mu.Lock()
+			a.dnsStart = time.Now()
+			a.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			a.mu.Lock()
+			a.trace.ReusedConn = info.Reused
+			if info.Conn != nil {
+				a.trace.RemoteAddress = info.Conn.RemoteAddr().String()
+			}
+			a.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			a.mu.Lock()
+			a.trace.Timings.TTFB = time.Since(a.start)
+			a.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			a.mu.Lock()
+			a.trace.Timings.TLSHandshake = time.Since(a.tlsStart)
+			a.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			a.mu.Lock()
+			a.tlsStart = time.Now()
+			a.mu.Unlock()
+		},
+	})
+}
+
+// finish will complete the trace of the attempt and return a copy of it
+func (a *attemptTracer) finish(resp *http.Response, err error) *AttemptTrace {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.trace.Timings.Total = time.Since(a.start)
+	if err != nil {
+		a.trace.Error = err.Error()
+	} else if resp != nil {
+		a.trace.StatusCode = resp.StatusCode
+	}
+	trace := *a.trace
+	return &trace
+}
+
+// detachedContext is a context that keeps the values (spans, etc) of the parent but not its cancellation
+type detachedContext struct {
+	context.Context
+}
+
+// Deadline returns no deadline
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+// Done returns nil (never canceled)
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+// Err returns nil (never canceled)
+func (detachedContext) Err() error { return nil }
@@ -0,0 +1,259 @@
+package polynym
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mockSpanKey is the context key of the mock span (the parent of the next span)
+type mockSpanKey struct{}
+
+// mockSpan is a span recorded by the mockTracer
+type mockSpan struct {
+	attributes map[string]interface{}
+	ended      bool
+	err        error
+	name       string
+	parent     *mockSpan
+}
+
+// End records the error
+func (m *mockSpan) End(err error) {
+	m.ended, m.err = true, err
+}
+
+// SetAttribute records the attribute
+func (m *mockSpan) SetAttribute(key string, value interface{}) {
+	m.attributes[key] = value
+}
+
+// mockTracer records the spans (parented using the context)
+type mockTracer struct {
+	mu    sync.Mutex
+	spans []*mockSpan
+}
+
+// StartSpan records a new span
+func (m *mockTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	span := &mockSpan{attributes: make(map[string]interface{}), name: name}
+	span.parent, _ = ctx.Value(mockSpanKey{}).(*mockSpan)
+	m.mu.Lock()
+	m.spans = append(m.spans, span)
+	m.mu.Unlock()
+	return context.WithValue(ctx, mockSpanKey{}, span), span
+}
+
+// newTraceServer will start a Polynym server that fails the first requests (503) and then returns the address
+func newTraceServer(t *testing.T, failures int64, tls bool) *httptest.Server {
+	var requests int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt64(&requests, 1) <= failures {
+			writeJSON(w, http.StatusServiceUnavailable, &GetAddressResponse{ErrorMessage: "unavailable"})
+			return
+		}
+		writeJSON(w, http.StatusOK, &GetAddressResponse{Address: "1Lti3s6AQNKTSgxnTyBREMa6XdHLBnPSKa"})
+	})
+	server := httptest.NewUnstartedServer(handler)
+	if tls {
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTraceClient will create a client for the server with the tracer (trusting the certificate of a TLS server)
+func newTraceClient(server *httptest.Server, endpoint string, tracer Tracer) *Client {
+	options := ClientDefaultOptions()
+	options.APIEndpoint = endpoint
+	options.Tracer = tracer
+	client := NewClient(options)
+	if server.TLS != nil {
+		client.httpClient = server.Client()
+	}
+	return client
+}
+
+// TestGetAddress_Timings will test the timings of the attempts on the last request
+func TestGetAddress_Timings(t *testing.T) {
+	t.Parallel()
+
+	t.Run("TLS", func(t *testing.T) {
+		server := newTraceServer(t, 0, true)
+		client := newTraceClient(server, server.URL, nil)
+
+		response, err := client.GetAddress("1mrz")
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if len(response.LastRequest.AttemptTraces) != 1 {
+			t.Fatalf("%s Failed: expected [%d] attempts, received: [%d]", t.Name(), 1, len(response.LastRequest.AttemptTraces))
+		}
+		trace := response.LastRequest.AttemptTraces[0]
+		if trace.Attempt != 1 || trace.StatusCode != http.StatusOK || trace.ReusedConn || trace.BackOff != 0 || len(trace.Error) > 0 {
+			t.Fatalf("%s Failed: unexpected trace [%+v]", t.Name(), trace)
+		} else if trace.Timings.Connect <= 0 || trace.Timings.TLSHandshake <= 0 || trace.Timings.TTFB <= 0 {
+			t.Fatalf("%s Failed: missing timings [%+v]", t.Name(), trace.Timings)
+		} else if trace.Timings.Total < trace.Timings.TTFB || trace.RemoteAddress != server.Listener.Addr().String() {
+			t.Fatalf("%s Failed: unexpected trace [%+v]", t.Name(), trace)
+		}
+
+	})
+
+	t.Run("DNS lookup", func(t *testing.T) {
+		server := newTraceServer(t, 0, false)
+		client := newTraceClient(server, strings.Replace(server.URL, "127.0.0.1", "localhost", 1), nil)
+		response, err := client.GetAddress("1mrz")
+		if err != nil {
+			t.Skipf("%s Skipped: localhost is not available [%s]", t.Name(), err.Error())
+		}
+		if trace := response.LastRequest.AttemptTraces[0]; trace.Timings.DNS <= 0 || trace.Timings.TLSHandshake != 0 {
+			t.Fatalf("%s Failed: unexpected timings [%+v]", t.Name(), trace.Timings)
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		server := newTraceServer(t, 2, false)
+		client := newTraceClient(server, server.URL, nil)
+		response, err := client.GetAddress("1mrz")
+		if err != nil {
+			t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+		} else if response.LastRequest.Attempts != 3 || len(response.LastRequest.AttemptTraces) != 3 {
+			t.Fatalf("%s Failed: expected [%d] attempts, received: [%d]", t.Name(), 3, len(response.LastRequest.AttemptTraces))
+		}
+		for i, expectedStatus := range []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK} {
+			trace := response.LastRequest.AttemptTraces[i]
+			if trace.Attempt != i+1 || trace.StatusCode != expectedStatus || (i > 0) != (trace.BackOff > 0) {
+				t.Errorf("%s Failed: unexpected trace [%d] [%+v]", t.Name(), i, trace)
+			}
+		}
+	})
+
+	t.Run("connection reuse", func(t *testing.T) {
+		server := newTraceServer(t, 0, false)
+		client := newTraceClient(server, server.URL, nil)
+		for i := 0; i < 2; i++ {
+			response, err := client.GetAddress("1mrz")
+			if err != nil {
+				t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+			}
+			trace := response.LastRequest.AttemptTraces[0]
+			if trace.ReusedConn != (i == 1) {
+				t.Fatalf("%s Failed: request [%d] expected reused [%v], received: [%v]", t.Name(), i, i == 1, trace.ReusedConn)
+			} else if (trace.Timings.Connect > 0) != (i == 0) {
+				t.Fatalf("%s Failed: request [%d] unexpected timings [%+v]", t.Name(), i, trace.Timings)
+			}
+		}
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		server := newTraceServer(t, 0, false)
+		client := newTraceClient(server, server.URL, nil)
+		client.retryCount = 0
+		server.Close()
+		_, err := client.GetAddress("1mrz")
+		var resolveErr *ResolveError
+		if !errors.As(err, &resolveErr) || resolveErr.LastRequest == nil || len(resolveErr.LastRequest.AttemptTraces) != 1 {
+			t.Fatalf("%s Failed: expected the attempt on the error [%v]", t.Name(), err)
+		} else if trace := resolveErr.LastRequest.AttemptTraces[0]; len(trace.Error) == 0 || trace.StatusCode != 0 {
+			t.Fatalf("%s Failed: unexpected trace [%+v]", t.Name(), trace)
+		}
+	})
+}
+
+// TestGetAddress_Tracer will test the spans started with the tracer
+func TestGetAddress_Tracer(t *testing.T) {
+	t.Parallel()
+
+	server := newTraceServer(t, 1, false)
+	tracer := new(mockTracer)
+	client := newTraceClient(server, server.URL, tracer)
+
+	ctx, parent := tracer.StartSpan(context.Background(), "caller")
+	if _, err := client.GetAddressWithContext(ctx, "1mrz"); err != nil {
+		t.Fatalf("%s Failed: error [%s]", t.Name(), err.Error())
+	}
+
+	// caller -> polynym.resolve -> polynym.request (x2)
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	if len(tracer.spans) != 4 {
+		t.Fatalf("%s Failed: expected [%d] spans, received: [%d]", t.Name(), 4, len(tracer.spans))
+	}
+	resolve := tracer.spans[1]
+	if resolve.name != SpanResolve || resolve.parent != parent || !resolve.ended || resolve.err != nil {
+		t.Fatalf("%s Failed: unexpected resolve span [%+v]", t.Name(), resolve)
+	} else if resolve.attributes["polynym.identifier_type"] != "relayx" || resolve.attributes["polynym.outcome"] != "success" ||
+		resolve.attributes["polynym.attempts"] != 2 || resolve.attributes["http.status_code"] != http.StatusOK {
+		t.Fatalf("%s Failed: unexpected resolve attributes [%v]", t.Name(), resolve.attributes)
+	}
+	for i, expectedStatus := range []int{http.StatusServiceUnavailable, http.StatusOK} {
+		request := tracer.spans[i+2]
+		if request.name != SpanRequest || request.parent != resolve || !request.ended {
+			t.Errorf("%s Failed: unexpected request span [%+v]", t.Name(), request)
+		} else if request.attributes["polynym.attempt"] != i+1 || request.attributes["http.status_code"] != expectedStatus ||
+			request.attributes["polynym.upstream"] != UpstreamPolynym || request.attributes["http.method"] != http.MethodGet {
+			t.Errorf("%s Failed: unexpected request attributes [%v]", t.Name(), request.attributes)
+		} else if ttfb, ok := request.attributes["polynym.ttfb"].(time.Duration); !ok || ttfb <= 0 {
+			t.Errorf("%s Failed: unexpected ttfb [%v]", t.Name(), request.attributes["polynym.ttfb"])
+		}
+	}
+}
+
+// TestDetachedContext will test the detachedContext
+func TestDetachedContext(t *testing.T) {
+	t.Parallel()
+
+	type key struct{}
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), key{}, "value"), time.Millisecond)
+	cancel()
+	ctx := detachedContext{parent}
+	if _, ok := ctx.Deadline(); ok || ctx.Done() != nil || ctx.Err() != nil {
+		t.Fatalf("%s Failed: the context should not be canceled", t.Name())
+	} else if ctx.Value(key{}) != "value" {
+		t.Fatalf("%s Failed: the values should be kept", t.Name())
+	}
+}
+
+// printTracer prints the span names and attempts
+type printTracer struct{}
+
+// StartSpan prints the name of the span
+func (printTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	fmt.Println("start:", name)
+	return ctx, noopSpan{}
+}
+
+// ExampleTracer example using a Tracer
+func ExampleTracer() {
+	client := &Client{httpClient: &mockHTTP{}, tracer: printTracer{}}
+	response, _ := client.GetAddress("1mrz")
+	fmt.Println("attempts:", len(response.LastRequest.AttemptTraces))
+	// Output:start: polynym.resolve
+	// start: polynym.request
+	// attempts: 1
+}
+
+// discardTracer starts spans that are discarded
+type discardTracer struct{}
+
+// StartSpan returns a span that does nothing
+func (discardTracer) StartSpan(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+// BenchmarkGetAddress_Tracer benchmarks the GetAddress method with a tracer
+func BenchmarkGetAddress_Tracer(b *testing.B) {
+	client := &Client{httpClient: &mockHTTP{}, tracer: discardTracer{}}
+	for i := 0; i < b.N; i++ {
+		_, _ = client.GetAddress("1mrz")
+	}
+}